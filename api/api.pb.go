@@ -26,15 +26,21 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type Request struct {
-	BannerId             uint64   `protobuf:"varint,1,opt,name=banner_id,json=bannerId,proto3" json:"banner_id,omitempty"`
-	ZoneId               uint64   `protobuf:"varint,2,opt,name=zone_id,json=zoneId,proto3" json:"zone_id,omitempty"`
-	Geo                  string   `protobuf:"bytes,3,opt,name=geo,proto3" json:"geo,omitempty"`
-	Browser              uint64   `protobuf:"varint,4,opt,name=browser,proto3" json:"browser,omitempty"`
-	OsVersion            string   `protobuf:"bytes,5,opt,name=os_version,json=osVersion,proto3" json:"os_version,omitempty"`
-	Platform             uint64   `protobuf:"varint,6,opt,name=platform,proto3" json:"platform,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	BannerId  uint64 `protobuf:"varint,1,opt,name=banner_id,json=bannerId,proto3" json:"banner_id,omitempty"`
+	ZoneId    uint64 `protobuf:"varint,2,opt,name=zone_id,json=zoneId,proto3" json:"zone_id,omitempty"`
+	Geo       string `protobuf:"bytes,3,opt,name=geo,proto3" json:"geo,omitempty"`
+	Browser   uint64 `protobuf:"varint,4,opt,name=browser,proto3" json:"browser,omitempty"`
+	OsVersion string `protobuf:"bytes,5,opt,name=os_version,json=osVersion,proto3" json:"os_version,omitempty"`
+	Platform  uint64 `protobuf:"varint,6,opt,name=platform,proto3" json:"platform,omitempty"`
+	// name of model or experiment from config,
+	// default model is used if empty
+	ModelName string `protobuf:"bytes,7,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	// features which are not fields of request,
+	// they are declared in config or model file
+	Features             map[string]string `protobuf:"bytes,8,rep,name=features,proto3" json:"features,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
 func (m *Request) Reset()         { *m = Request{} }
@@ -104,9 +110,34 @@ func (m *Request) GetPlatform() uint64 {
 	return 0
 }
 
+func (m *Request) GetModelName() string {
+	if m != nil {
+		return m.ModelName
+	}
+	return ""
+}
+
+func (m *Request) GetFeatures() map[string]string {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
 type Response struct {
-	Proba                float64  `protobuf:"fixed64,1,opt,name=proba,proto3" json:"proba,omitempty"`
-	Confidence           float64  `protobuf:"fixed64,2,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	Proba float64 `protobuf:"fixed64,1,opt,name=proba,proto3" json:"proba,omitempty"`
+	// confidence of prediction in range [0, 1], its definition
+	// is selected by "confidence" key of server config:
+	//  coverage - fraction of model variables whose values of request
+	//      are seen in training (default)
+	//  variance - 1/sqrt(1+pi*v/8) where v is variance of logit
+	//      estimated by goFTRL "n" accumulators of coefficients
+	//  constant - always 1
+	Confidence float64 `protobuf:"fixed64,2,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	// name of model which has served the request
+	ModelName string `protobuf:"bytes,3,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	// experiment arm, empty if request is not a part of experiment
+	Variant              string   `protobuf:"bytes,4,opt,name=variant,proto3" json:"variant,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -151,112 +182,1999 @@ func (m *Response) GetConfidence() float64 {
 	return 0
 }
 
-func init() {
-	proto.RegisterType((*Request)(nil), "inferencer.Request")
-	proto.RegisterType((*Response)(nil), "inferencer.Response")
+func (m *Response) GetModelName() string {
+	if m != nil {
+		return m.ModelName
+	}
+	return ""
 }
 
-func init() { proto.RegisterFile("api.proto", fileDescriptor_00212fb1f9d3bf1c) }
+func (m *Response) GetVariant() string {
+	if m != nil {
+		return m.Variant
+	}
+	return ""
+}
 
-var fileDescriptor_00212fb1f9d3bf1c = []byte{
-	// 297 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x90, 0x3b, 0x4e, 0x03, 0x31,
-	0x10, 0x86, 0xe5, 0xbc, 0x77, 0x44, 0x11, 0x99, 0x20, 0x56, 0x09, 0xa0, 0x68, 0xab, 0x88, 0x22,
-	0x2b, 0xa0, 0xa3, 0xa2, 0x4d, 0x17, 0x6d, 0x91, 0x36, 0xf2, 0xae, 0x27, 0xc1, 0xd2, 0xc6, 0x63,
-	0x6c, 0x13, 0x10, 0x25, 0x57, 0xe0, 0x0e, 0x5c, 0x88, 0x2b, 0x70, 0x10, 0xb4, 0x36, 0xe1, 0xd1,
-	0xf9, 0xff, 0x66, 0x46, 0x33, 0xfe, 0x20, 0x11, 0x46, 0xcd, 0x8d, 0x25, 0x4f, 0x1c, 0x94, 0xde,
-	0xa0, 0x45, 0x5d, 0xa1, 0x1d, 0x9f, 0x6d, 0x89, 0xb6, 0x35, 0xe6, 0xc2, 0xa8, 0x5c, 0x68, 0x4d,
-	0x5e, 0x78, 0x45, 0xda, 0xc5, 0xce, 0xec, 0x9d, 0x41, 0xbf, 0xc0, 0x87, 0x47, 0x74, 0x9e, 0x4f,
-	0x20, 0x29, 0x85, 0xd6, 0x68, 0xd7, 0x4a, 0xa6, 0x6c, 0xca, 0x66, 0x9d, 0x62, 0x10, 0xc1, 0x42,
-	0xf2, 0x53, 0xe8, 0xbf, 0x90, 0xc6, 0xa6, 0xd4, 0x0a, 0xa5, 0x5e, 0x13, 0x17, 0x92, 0x0f, 0xa1,
-	0xbd, 0x45, 0x4a, 0xdb, 0x53, 0x36, 0x4b, 0x8a, 0xe6, 0xc9, 0x53, 0xe8, 0x97, 0x96, 0x9e, 0x1c,
-	0xda, 0xb4, 0x13, 0x5a, 0x0f, 0x91, 0x9f, 0x03, 0x90, 0x5b, 0xef, 0xd1, 0x3a, 0x45, 0x3a, 0xed,
-	0x86, 0x91, 0x84, 0xdc, 0x2a, 0x02, 0x3e, 0x86, 0x81, 0xa9, 0x85, 0xdf, 0x90, 0xdd, 0xa5, 0xbd,
-	0xb8, 0xff, 0x90, 0xb3, 0x3b, 0x18, 0x14, 0xe8, 0x0c, 0x69, 0x87, 0x7c, 0x04, 0x5d, 0x63, 0xa9,
-	0x14, 0xe1, 0x48, 0x56, 0xc4, 0xc0, 0x2f, 0x00, 0x2a, 0xd2, 0x1b, 0x25, 0x9b, 0x7f, 0x87, 0x23,
-	0x59, 0xf1, 0x87, 0x5c, 0x4b, 0x80, 0xc5, 0x8f, 0x16, 0xbe, 0x82, 0xa3, 0xa5, 0x45, 0xa9, 0x2a,
-	0xbf, 0x0c, 0xd3, 0xc7, 0xf3, 0x5f, 0x67, 0xf3, 0x6f, 0x23, 0xe3, 0xd1, 0x7f, 0x18, 0xd7, 0x67,
-	0x93, 0xd7, 0x8f, 0xcf, 0xb7, 0xd6, 0x49, 0x36, 0xcc, 0xf7, 0x57, 0x39, 0x3e, 0x8b, 0x9d, 0xa9,
-	0x31, 0xc7, 0xea, 0x9e, 0x6e, 0xd9, 0x65, 0xd9, 0x0b, 0x5e, 0x6f, 0xbe, 0x02, 0x00, 0x00, 0xff,
-	0xff, 0x7b, 0xfa, 0xfc, 0x04, 0x8e, 0x01, 0x00, 0x00,
+type Prediction struct {
+	// linear score of model: bias plus coefficients
+	Score float64 `protobuf:"fixed64,1,opt,name=score,proto3" json:"score,omitempty"`
+	// score transformed by link function, calibrated
+	// for logistic models
+	Prediction float64 `protobuf:"fixed64,2,opt,name=prediction,proto3" json:"prediction,omitempty"`
+	// link function of model: logistic, identity, log or probit
+	Link string `protobuf:"bytes,3,opt,name=link,proto3" json:"link,omitempty"`
+	// confidence of prediction, see Response
+	Confidence           float64  `protobuf:"fixed64,4,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	ModelName            string   `protobuf:"bytes,5,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	Variant              string   `protobuf:"bytes,6,opt,name=variant,proto3" json:"variant,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+func (m *Prediction) Reset()         { *m = Prediction{} }
+func (m *Prediction) String() string { return proto.CompactTextString(m) }
+func (*Prediction) ProtoMessage()    {}
+func (*Prediction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{2}
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func (m *Prediction) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Prediction.Unmarshal(m, b)
+}
+func (m *Prediction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Prediction.Marshal(b, m, deterministic)
+}
+func (m *Prediction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Prediction.Merge(m, src)
+}
+func (m *Prediction) XXX_Size() int {
+	return xxx_messageInfo_Prediction.Size(m)
+}
+func (m *Prediction) XXX_DiscardUnknown() {
+	xxx_messageInfo_Prediction.DiscardUnknown(m)
+}
 
-// InferencerClient is the client API for Inferencer service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type InferencerClient interface {
-	PredictProba(ctx context.Context, in *Request, opts ...grpc.CallOption) (*Response, error)
+var xxx_messageInfo_Prediction proto.InternalMessageInfo
+
+func (m *Prediction) GetScore() float64 {
+	if m != nil {
+		return m.Score
+	}
+	return 0
 }
 
-type inferencerClient struct {
-	cc *grpc.ClientConn
+func (m *Prediction) GetPrediction() float64 {
+	if m != nil {
+		return m.Prediction
+	}
+	return 0
 }
 
-func NewInferencerClient(cc *grpc.ClientConn) InferencerClient {
-	return &inferencerClient{cc}
+func (m *Prediction) GetLink() string {
+	if m != nil {
+		return m.Link
+	}
+	return ""
 }
 
-func (c *inferencerClient) PredictProba(ctx context.Context, in *Request, opts ...grpc.CallOption) (*Response, error) {
-	out := new(Response)
-	err := c.cc.Invoke(ctx, "/inferencer.Inferencer/PredictProba", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *Prediction) GetConfidence() float64 {
+	if m != nil {
+		return m.Confidence
 	}
-	return out, nil
+	return 0
 }
 
-// InferencerServer is the server API for Inferencer service.
-type InferencerServer interface {
-	PredictProba(context.Context, *Request) (*Response, error)
+func (m *Prediction) GetModelName() string {
+	if m != nil {
+		return m.ModelName
+	}
+	return ""
 }
 
-// UnimplementedInferencerServer can be embedded to have forward compatible implementations.
-type UnimplementedInferencerServer struct {
+func (m *Prediction) GetVariant() string {
+	if m != nil {
+		return m.Variant
+	}
+	return ""
 }
 
-func (*UnimplementedInferencerServer) PredictProba(ctx context.Context, req *Request) (*Response, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method PredictProba not implemented")
+type ClassesResponse struct {
+	// classes in the order of model file
+	Classes              []*ClassProba `protobuf:"bytes,1,rep,name=classes,proto3" json:"classes,omitempty"`
+	ModelName            string        `protobuf:"bytes,2,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	Variant              string        `protobuf:"bytes,3,opt,name=variant,proto3" json:"variant,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
 }
 
-func RegisterInferencerServer(s *grpc.Server, srv InferencerServer) {
-	s.RegisterService(&_Inferencer_serviceDesc, srv)
+func (m *ClassesResponse) Reset()         { *m = ClassesResponse{} }
+func (m *ClassesResponse) String() string { return proto.CompactTextString(m) }
+func (*ClassesResponse) ProtoMessage()    {}
+func (*ClassesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{3}
 }
 
-func _Inferencer_PredictProba_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Request)
-	if err := dec(in); err != nil {
-		return nil, err
+func (m *ClassesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClassesResponse.Unmarshal(m, b)
+}
+func (m *ClassesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClassesResponse.Marshal(b, m, deterministic)
+}
+func (m *ClassesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClassesResponse.Merge(m, src)
+}
+func (m *ClassesResponse) XXX_Size() int {
+	return xxx_messageInfo_ClassesResponse.Size(m)
+}
+func (m *ClassesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClassesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ClassesResponse proto.InternalMessageInfo
+
+func (m *ClassesResponse) GetClasses() []*ClassProba {
+	if m != nil {
+		return m.Classes
 	}
-	if interceptor == nil {
-		return srv.(InferencerServer).PredictProba(ctx, in)
+	return nil
+}
+
+func (m *ClassesResponse) GetModelName() string {
+	if m != nil {
+		return m.ModelName
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/inferencer.Inferencer/PredictProba",
+	return ""
+}
+
+func (m *ClassesResponse) GetVariant() string {
+	if m != nil {
+		return m.Variant
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(InferencerServer).PredictProba(ctx, req.(*Request))
+	return ""
+}
+
+type ClassProba struct {
+	Label string `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	// linear score of class
+	Score float64 `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	// softmax of scores
+	Proba                float64  `protobuf:"fixed64,3,opt,name=proba,proto3" json:"proba,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ClassProba) Reset()         { *m = ClassProba{} }
+func (m *ClassProba) String() string { return proto.CompactTextString(m) }
+func (*ClassProba) ProtoMessage()    {}
+func (*ClassProba) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{4}
+}
+
+func (m *ClassProba) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClassProba.Unmarshal(m, b)
+}
+func (m *ClassProba) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClassProba.Marshal(b, m, deterministic)
+}
+func (m *ClassProba) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClassProba.Merge(m, src)
+}
+func (m *ClassProba) XXX_Size() int {
+	return xxx_messageInfo_ClassProba.Size(m)
+}
+func (m *ClassProba) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClassProba.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ClassProba proto.InternalMessageInfo
+
+func (m *ClassProba) GetLabel() string {
+	if m != nil {
+		return m.Label
 	}
-	return interceptor(ctx, in, info, handler)
+	return ""
 }
 
-var _Inferencer_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "inferencer.Inferencer",
-	HandlerType: (*InferencerServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "PredictProba",
-			Handler:    _Inferencer_PredictProba_Handler,
+func (m *ClassProba) GetScore() float64 {
+	if m != nil {
+		return m.Score
+	}
+	return 0
+}
+
+func (m *ClassProba) GetProba() float64 {
+	if m != nil {
+		return m.Proba
+	}
+	return 0
+}
+
+type FeedbackRequest struct {
+	Request *Request `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	// outcome of request
+	Click                bool     `protobuf:"varint,2,opt,name=click,proto3" json:"click,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FeedbackRequest) Reset()         { *m = FeedbackRequest{} }
+func (m *FeedbackRequest) String() string { return proto.CompactTextString(m) }
+func (*FeedbackRequest) ProtoMessage()    {}
+func (*FeedbackRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{5}
+}
+
+func (m *FeedbackRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FeedbackRequest.Unmarshal(m, b)
+}
+func (m *FeedbackRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FeedbackRequest.Marshal(b, m, deterministic)
+}
+func (m *FeedbackRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FeedbackRequest.Merge(m, src)
+}
+func (m *FeedbackRequest) XXX_Size() int {
+	return xxx_messageInfo_FeedbackRequest.Size(m)
+}
+func (m *FeedbackRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FeedbackRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FeedbackRequest proto.InternalMessageInfo
+
+func (m *FeedbackRequest) GetRequest() *Request {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+func (m *FeedbackRequest) GetClick() bool {
+	if m != nil {
+		return m.Click
+	}
+	return false
+}
+
+type FeedbackResponse struct {
+	// probability of request before update, it's not calibrated
+	Proba float64 `protobuf:"fixed64,1,opt,name=proba,proto3" json:"proba,omitempty"`
+	// model which has been updated
+	ModelName string `protobuf:"bytes,2,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	Variant   string `protobuf:"bytes,3,opt,name=variant,proto3" json:"variant,omitempty"`
+	// number of updates since model is loaded
+	Updates              uint64   `protobuf:"varint,4,opt,name=updates,proto3" json:"updates,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FeedbackResponse) Reset()         { *m = FeedbackResponse{} }
+func (m *FeedbackResponse) String() string { return proto.CompactTextString(m) }
+func (*FeedbackResponse) ProtoMessage()    {}
+func (*FeedbackResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{6}
+}
+
+func (m *FeedbackResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FeedbackResponse.Unmarshal(m, b)
+}
+func (m *FeedbackResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FeedbackResponse.Marshal(b, m, deterministic)
+}
+func (m *FeedbackResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FeedbackResponse.Merge(m, src)
+}
+func (m *FeedbackResponse) XXX_Size() int {
+	return xxx_messageInfo_FeedbackResponse.Size(m)
+}
+func (m *FeedbackResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_FeedbackResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FeedbackResponse proto.InternalMessageInfo
+
+func (m *FeedbackResponse) GetProba() float64 {
+	if m != nil {
+		return m.Proba
+	}
+	return 0
+}
+
+func (m *FeedbackResponse) GetModelName() string {
+	if m != nil {
+		return m.ModelName
+	}
+	return ""
+}
+
+func (m *FeedbackResponse) GetVariant() string {
+	if m != nil {
+		return m.Variant
+	}
+	return ""
+}
+
+func (m *FeedbackResponse) GetUpdates() uint64 {
+	if m != nil {
+		return m.Updates
+	}
+	return 0
+}
+
+type BatchRequest struct {
+	Requests             []*Request `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *BatchRequest) Reset()         { *m = BatchRequest{} }
+func (m *BatchRequest) String() string { return proto.CompactTextString(m) }
+func (*BatchRequest) ProtoMessage()    {}
+func (*BatchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{7}
+}
+
+func (m *BatchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchRequest.Unmarshal(m, b)
+}
+func (m *BatchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchRequest.Marshal(b, m, deterministic)
+}
+func (m *BatchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchRequest.Merge(m, src)
+}
+func (m *BatchRequest) XXX_Size() int {
+	return xxx_messageInfo_BatchRequest.Size(m)
+}
+func (m *BatchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchRequest proto.InternalMessageInfo
+
+func (m *BatchRequest) GetRequests() []*Request {
+	if m != nil {
+		return m.Requests
+	}
+	return nil
+}
+
+type BatchResponse struct {
+	Items                []*BatchItem `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *BatchResponse) Reset()         { *m = BatchResponse{} }
+func (m *BatchResponse) String() string { return proto.CompactTextString(m) }
+func (*BatchResponse) ProtoMessage()    {}
+func (*BatchResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{8}
+}
+
+func (m *BatchResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchResponse.Unmarshal(m, b)
+}
+func (m *BatchResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchResponse.Marshal(b, m, deterministic)
+}
+func (m *BatchResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchResponse.Merge(m, src)
+}
+func (m *BatchResponse) XXX_Size() int {
+	return xxx_messageInfo_BatchResponse.Size(m)
+}
+func (m *BatchResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchResponse proto.InternalMessageInfo
+
+func (m *BatchResponse) GetItems() []*BatchItem {
+	if m != nil {
+		return m.Items
+	}
+	return nil
+}
+
+// BatchItem is the result of single request of batch,
+// either response or grpc error code with message
+type BatchItem struct {
+	Response             *Response `protobuf:"bytes,1,opt,name=response,proto3" json:"response,omitempty"`
+	Code                 int32     `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Error                string    `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *BatchItem) Reset()         { *m = BatchItem{} }
+func (m *BatchItem) String() string { return proto.CompactTextString(m) }
+func (*BatchItem) ProtoMessage()    {}
+func (*BatchItem) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{9}
+}
+
+func (m *BatchItem) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchItem.Unmarshal(m, b)
+}
+func (m *BatchItem) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchItem.Marshal(b, m, deterministic)
+}
+func (m *BatchItem) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchItem.Merge(m, src)
+}
+func (m *BatchItem) XXX_Size() int {
+	return xxx_messageInfo_BatchItem.Size(m)
+}
+func (m *BatchItem) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchItem.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchItem proto.InternalMessageInfo
+
+func (m *BatchItem) GetResponse() *Response {
+	if m != nil {
+		return m.Response
+	}
+	return nil
+}
+
+func (m *BatchItem) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *BatchItem) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type StreamRequest struct {
+	// correlation id, it's copied to response
+	Id                   uint64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Request              *Request `protobuf:"bytes,2,opt,name=request,proto3" json:"request,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StreamRequest) Reset()         { *m = StreamRequest{} }
+func (m *StreamRequest) String() string { return proto.CompactTextString(m) }
+func (*StreamRequest) ProtoMessage()    {}
+func (*StreamRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{10}
+}
+
+func (m *StreamRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StreamRequest.Unmarshal(m, b)
+}
+func (m *StreamRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StreamRequest.Marshal(b, m, deterministic)
+}
+func (m *StreamRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StreamRequest.Merge(m, src)
+}
+func (m *StreamRequest) XXX_Size() int {
+	return xxx_messageInfo_StreamRequest.Size(m)
+}
+func (m *StreamRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StreamRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StreamRequest proto.InternalMessageInfo
+
+func (m *StreamRequest) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *StreamRequest) GetRequest() *Request {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+// StreamResponse carries either response or grpc error
+// code with message for request with the same id
+type StreamResponse struct {
+	Id                   uint64    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Response             *Response `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+	Code                 int32     `protobuf:"varint,3,opt,name=code,proto3" json:"code,omitempty"`
+	Error                string    `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *StreamResponse) Reset()         { *m = StreamResponse{} }
+func (m *StreamResponse) String() string { return proto.CompactTextString(m) }
+func (*StreamResponse) ProtoMessage()    {}
+func (*StreamResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{11}
+}
+
+func (m *StreamResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StreamResponse.Unmarshal(m, b)
+}
+func (m *StreamResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StreamResponse.Marshal(b, m, deterministic)
+}
+func (m *StreamResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StreamResponse.Merge(m, src)
+}
+func (m *StreamResponse) XXX_Size() int {
+	return xxx_messageInfo_StreamResponse.Size(m)
+}
+func (m *StreamResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_StreamResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StreamResponse proto.InternalMessageInfo
+
+func (m *StreamResponse) GetId() uint64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *StreamResponse) GetResponse() *Response {
+	if m != nil {
+		return m.Response
+	}
+	return nil
+}
+
+func (m *StreamResponse) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *StreamResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type ReloadRequest struct {
+	// name of model from config, default model is reloaded if empty
+	ModelName            string   `protobuf:"bytes,1,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReloadRequest) Reset()         { *m = ReloadRequest{} }
+func (m *ReloadRequest) String() string { return proto.CompactTextString(m) }
+func (*ReloadRequest) ProtoMessage()    {}
+func (*ReloadRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{12}
+}
+
+func (m *ReloadRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReloadRequest.Unmarshal(m, b)
+}
+func (m *ReloadRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReloadRequest.Marshal(b, m, deterministic)
+}
+func (m *ReloadRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReloadRequest.Merge(m, src)
+}
+func (m *ReloadRequest) XXX_Size() int {
+	return xxx_messageInfo_ReloadRequest.Size(m)
+}
+func (m *ReloadRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReloadRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReloadRequest proto.InternalMessageInfo
+
+func (m *ReloadRequest) GetModelName() string {
+	if m != nil {
+		return m.ModelName
+	}
+	return ""
+}
+
+type ReloadResponse struct {
+	Model                string   `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	LoadedAt             int64    `protobuf:"varint,2,opt,name=loaded_at,json=loadedAt,proto3" json:"loaded_at,omitempty"`
+	ModelName            string   `protobuf:"bytes,3,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReloadResponse) Reset()         { *m = ReloadResponse{} }
+func (m *ReloadResponse) String() string { return proto.CompactTextString(m) }
+func (*ReloadResponse) ProtoMessage()    {}
+func (*ReloadResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{13}
+}
+
+func (m *ReloadResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReloadResponse.Unmarshal(m, b)
+}
+func (m *ReloadResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReloadResponse.Marshal(b, m, deterministic)
+}
+func (m *ReloadResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReloadResponse.Merge(m, src)
+}
+func (m *ReloadResponse) XXX_Size() int {
+	return xxx_messageInfo_ReloadResponse.Size(m)
+}
+func (m *ReloadResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReloadResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReloadResponse proto.InternalMessageInfo
+
+func (m *ReloadResponse) GetModel() string {
+	if m != nil {
+		return m.Model
+	}
+	return ""
+}
+
+func (m *ReloadResponse) GetLoadedAt() int64 {
+	if m != nil {
+		return m.LoadedAt
+	}
+	return 0
+}
+
+func (m *ReloadResponse) GetModelName() string {
+	if m != nil {
+		return m.ModelName
+	}
+	return ""
+}
+
+type ModelInfoRequest struct {
+	// name of model from config, default model is described if empty
+	ModelName            string   `protobuf:"bytes,1,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ModelInfoRequest) Reset()         { *m = ModelInfoRequest{} }
+func (m *ModelInfoRequest) String() string { return proto.CompactTextString(m) }
+func (*ModelInfoRequest) ProtoMessage()    {}
+func (*ModelInfoRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{14}
+}
+
+func (m *ModelInfoRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ModelInfoRequest.Unmarshal(m, b)
+}
+func (m *ModelInfoRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ModelInfoRequest.Marshal(b, m, deterministic)
+}
+func (m *ModelInfoRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ModelInfoRequest.Merge(m, src)
+}
+func (m *ModelInfoRequest) XXX_Size() int {
+	return xxx_messageInfo_ModelInfoRequest.Size(m)
+}
+func (m *ModelInfoRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ModelInfoRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ModelInfoRequest proto.InternalMessageInfo
+
+func (m *ModelInfoRequest) GetModelName() string {
+	if m != nil {
+		return m.ModelName
+	}
+	return ""
+}
+
+type ModelInfoResponse struct {
+	ModelName string `protobuf:"bytes,1,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	Path      string `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	// SHA-256 of model file, hex encoded
+	Checksum string `protobuf:"bytes,3,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	// "text" or "binary"
+	Format   string         `protobuf:"bytes,4,opt,name=format,proto3" json:"format,omitempty"`
+	LoadedAt int64          `protobuf:"varint,5,opt,name=loaded_at,json=loadedAt,proto3" json:"loaded_at,omitempty"`
+	Bias     float64        `protobuf:"fixed64,6,opt,name=bias,proto3" json:"bias,omitempty"`
+	Features []*FeatureInfo `protobuf:"bytes,7,rep,name=features,proto3" json:"features,omitempty"`
+	// variables of single feature and interactions
+	Variables []*VariableInfo `protobuf:"bytes,8,rep,name=variables,proto3" json:"variables,omitempty"`
+	// hash function and size of weight vector of hashed model
+	Hash        string           `protobuf:"bytes,9,opt,name=hash,proto3" json:"hash,omitempty"`
+	HashBuckets uint32           `protobuf:"varint,10,opt,name=hash_buckets,json=hashBuckets,proto3" json:"hash_buckets,omitempty"`
+	Calibration *CalibrationInfo `protobuf:"bytes,11,opt,name=calibration,proto3" json:"calibration,omitempty"`
+	// link function of model, see Prediction,
+	// it's "softmax" for multi-class model
+	Link string `protobuf:"bytes,12,opt,name=link,proto3" json:"link,omitempty"`
+	// labels of classes of multi-class model
+	Classes              []string `protobuf:"bytes,13,rep,name=classes,proto3" json:"classes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ModelInfoResponse) Reset()         { *m = ModelInfoResponse{} }
+func (m *ModelInfoResponse) String() string { return proto.CompactTextString(m) }
+func (*ModelInfoResponse) ProtoMessage()    {}
+func (*ModelInfoResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{15}
+}
+
+func (m *ModelInfoResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ModelInfoResponse.Unmarshal(m, b)
+}
+func (m *ModelInfoResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ModelInfoResponse.Marshal(b, m, deterministic)
+}
+func (m *ModelInfoResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ModelInfoResponse.Merge(m, src)
+}
+func (m *ModelInfoResponse) XXX_Size() int {
+	return xxx_messageInfo_ModelInfoResponse.Size(m)
+}
+func (m *ModelInfoResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ModelInfoResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ModelInfoResponse proto.InternalMessageInfo
+
+func (m *ModelInfoResponse) GetModelName() string {
+	if m != nil {
+		return m.ModelName
+	}
+	return ""
+}
+
+func (m *ModelInfoResponse) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *ModelInfoResponse) GetChecksum() string {
+	if m != nil {
+		return m.Checksum
+	}
+	return ""
+}
+
+func (m *ModelInfoResponse) GetFormat() string {
+	if m != nil {
+		return m.Format
+	}
+	return ""
+}
+
+func (m *ModelInfoResponse) GetLoadedAt() int64 {
+	if m != nil {
+		return m.LoadedAt
+	}
+	return 0
+}
+
+func (m *ModelInfoResponse) GetBias() float64 {
+	if m != nil {
+		return m.Bias
+	}
+	return 0
+}
+
+func (m *ModelInfoResponse) GetFeatures() []*FeatureInfo {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
+func (m *ModelInfoResponse) GetVariables() []*VariableInfo {
+	if m != nil {
+		return m.Variables
+	}
+	return nil
+}
+
+func (m *ModelInfoResponse) GetHash() string {
+	if m != nil {
+		return m.Hash
+	}
+	return ""
+}
+
+func (m *ModelInfoResponse) GetHashBuckets() uint32 {
+	if m != nil {
+		return m.HashBuckets
+	}
+	return 0
+}
+
+func (m *ModelInfoResponse) GetCalibration() *CalibrationInfo {
+	if m != nil {
+		return m.Calibration
+	}
+	return nil
+}
+
+func (m *ModelInfoResponse) GetLink() string {
+	if m != nil {
+		return m.Link
+	}
+	return ""
+}
+
+func (m *ModelInfoResponse) GetClasses() []string {
+	if m != nil {
+		return m.Classes
+	}
+	return nil
+}
+
+type ExportRequest struct {
+	// name of model from config, default model is exported if empty
+	ModelName string `protobuf:"bytes,1,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	// export copy updated by online learning, it differs from
+	// serving model if updates apply to shadow copy only
+	Online               bool     `protobuf:"varint,2,opt,name=online,proto3" json:"online,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExportRequest) Reset()         { *m = ExportRequest{} }
+func (m *ExportRequest) String() string { return proto.CompactTextString(m) }
+func (*ExportRequest) ProtoMessage()    {}
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{16}
+}
+
+func (m *ExportRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExportRequest.Unmarshal(m, b)
+}
+func (m *ExportRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExportRequest.Marshal(b, m, deterministic)
+}
+func (m *ExportRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportRequest.Merge(m, src)
+}
+func (m *ExportRequest) XXX_Size() int {
+	return xxx_messageInfo_ExportRequest.Size(m)
+}
+func (m *ExportRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportRequest proto.InternalMessageInfo
+
+func (m *ExportRequest) GetModelName() string {
+	if m != nil {
+		return m.ModelName
+	}
+	return ""
+}
+
+func (m *ExportRequest) GetOnline() bool {
+	if m != nil {
+		return m.Online
+	}
+	return false
+}
+
+type ExportResponse struct {
+	ModelName string `protobuf:"bytes,1,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	// model in text format
+	Model                []byte   `protobuf:"bytes,2,opt,name=model,proto3" json:"model,omitempty"`
+	LoadedAt             int64    `protobuf:"varint,3,opt,name=loaded_at,json=loadedAt,proto3" json:"loaded_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExportResponse) Reset()         { *m = ExportResponse{} }
+func (m *ExportResponse) String() string { return proto.CompactTextString(m) }
+func (*ExportResponse) ProtoMessage()    {}
+func (*ExportResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{17}
+}
+
+func (m *ExportResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExportResponse.Unmarshal(m, b)
+}
+func (m *ExportResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExportResponse.Marshal(b, m, deterministic)
+}
+func (m *ExportResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportResponse.Merge(m, src)
+}
+func (m *ExportResponse) XXX_Size() int {
+	return xxx_messageInfo_ExportResponse.Size(m)
+}
+func (m *ExportResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportResponse proto.InternalMessageInfo
+
+func (m *ExportResponse) GetModelName() string {
+	if m != nil {
+		return m.ModelName
+	}
+	return ""
+}
+
+func (m *ExportResponse) GetModel() []byte {
+	if m != nil {
+		return m.Model
+	}
+	return nil
+}
+
+func (m *ExportResponse) GetLoadedAt() int64 {
+	if m != nil {
+		return m.LoadedAt
+	}
+	return 0
+}
+
+// CalibrationInfo describes how score of model is mapped to probability
+type CalibrationInfo struct {
+	// "none", "downsampling", "platt" or "isotonic"
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// fraction of negatives kept in training, for downsampling
+	Rate float64 `protobuf:"fixed64,2,opt,name=rate,proto3" json:"rate,omitempty"`
+	// probability is sigmoid(a*score + b), for platt
+	A float64 `protobuf:"fixed64,3,opt,name=a,proto3" json:"a,omitempty"`
+	B float64 `protobuf:"fixed64,4,opt,name=b,proto3" json:"b,omitempty"`
+	// path to mapping table and number of its points, for isotonic
+	Table                string   `protobuf:"bytes,5,opt,name=table,proto3" json:"table,omitempty"`
+	Points               uint32   `protobuf:"varint,6,opt,name=points,proto3" json:"points,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CalibrationInfo) Reset()         { *m = CalibrationInfo{} }
+func (m *CalibrationInfo) String() string { return proto.CompactTextString(m) }
+func (*CalibrationInfo) ProtoMessage()    {}
+func (*CalibrationInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{18}
+}
+
+func (m *CalibrationInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CalibrationInfo.Unmarshal(m, b)
+}
+func (m *CalibrationInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CalibrationInfo.Marshal(b, m, deterministic)
+}
+func (m *CalibrationInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CalibrationInfo.Merge(m, src)
+}
+func (m *CalibrationInfo) XXX_Size() int {
+	return xxx_messageInfo_CalibrationInfo.Size(m)
+}
+func (m *CalibrationInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_CalibrationInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CalibrationInfo proto.InternalMessageInfo
+
+func (m *CalibrationInfo) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *CalibrationInfo) GetRate() float64 {
+	if m != nil {
+		return m.Rate
+	}
+	return 0
+}
+
+func (m *CalibrationInfo) GetA() float64 {
+	if m != nil {
+		return m.A
+	}
+	return 0
+}
+
+func (m *CalibrationInfo) GetB() float64 {
+	if m != nil {
+		return m.B
+	}
+	return 0
+}
+
+func (m *CalibrationInfo) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *CalibrationInfo) GetPoints() uint32 {
+	if m != nil {
+		return m.Points
+	}
+	return 0
+}
+
+type FeatureInfo struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// request field, features map is used if empty
+	Field string `protobuf:"bytes,2,opt,name=field,proto3" json:"field,omitempty"`
+	// "categorical" or "numeric", empty if model doesn't use feature
+	Kind string `protobuf:"bytes,3,opt,name=kind,proto3" json:"kind,omitempty"`
+	// number of distinct values seen in training
+	VocabularySize uint32 `protobuf:"varint,4,opt,name=vocabulary_size,json=vocabularySize,proto3" json:"vocabulary_size,omitempty"`
+	// boundaries of bucketized numeric feature
+	Buckets []float64 `protobuf:"fixed64,5,rep,packed,name=buckets,proto3" json:"buckets,omitempty"`
+	// weight of numeric feature, it's set if has_weight is true
+	Weight               float64  `protobuf:"fixed64,6,opt,name=weight,proto3" json:"weight,omitempty"`
+	HasWeight            bool     `protobuf:"varint,7,opt,name=has_weight,json=hasWeight,proto3" json:"has_weight,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FeatureInfo) Reset()         { *m = FeatureInfo{} }
+func (m *FeatureInfo) String() string { return proto.CompactTextString(m) }
+func (*FeatureInfo) ProtoMessage()    {}
+func (*FeatureInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{19}
+}
+
+func (m *FeatureInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FeatureInfo.Unmarshal(m, b)
+}
+func (m *FeatureInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FeatureInfo.Marshal(b, m, deterministic)
+}
+func (m *FeatureInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FeatureInfo.Merge(m, src)
+}
+func (m *FeatureInfo) XXX_Size() int {
+	return xxx_messageInfo_FeatureInfo.Size(m)
+}
+func (m *FeatureInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_FeatureInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FeatureInfo proto.InternalMessageInfo
+
+func (m *FeatureInfo) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *FeatureInfo) GetField() string {
+	if m != nil {
+		return m.Field
+	}
+	return ""
+}
+
+func (m *FeatureInfo) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *FeatureInfo) GetVocabularySize() uint32 {
+	if m != nil {
+		return m.VocabularySize
+	}
+	return 0
+}
+
+func (m *FeatureInfo) GetBuckets() []float64 {
+	if m != nil {
+		return m.Buckets
+	}
+	return nil
+}
+
+func (m *FeatureInfo) GetWeight() float64 {
+	if m != nil {
+		return m.Weight
+	}
+	return 0
+}
+
+func (m *FeatureInfo) GetHasWeight() bool {
+	if m != nil {
+		return m.HasWeight
+	}
+	return false
+}
+
+type VariableInfo struct {
+	// name as in model file, e.g. geoXXbrowser
+	Name     string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Features []string `protobuf:"bytes,2,rep,name=features,proto3" json:"features,omitempty"`
+	// number of coefficients, weights are not counted for hashed variables
+	Coefficients uint32 `protobuf:"varint,3,opt,name=coefficients,proto3" json:"coefficients,omitempty"`
+	// "fit.other" coefficient, it's set if has_other is true
+	Other                float64  `protobuf:"fixed64,4,opt,name=other,proto3" json:"other,omitempty"`
+	HasOther             bool     `protobuf:"varint,5,opt,name=has_other,json=hasOther,proto3" json:"has_other,omitempty"`
+	Hashed               bool     `protobuf:"varint,6,opt,name=hashed,proto3" json:"hashed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VariableInfo) Reset()         { *m = VariableInfo{} }
+func (m *VariableInfo) String() string { return proto.CompactTextString(m) }
+func (*VariableInfo) ProtoMessage()    {}
+func (*VariableInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{20}
+}
+
+func (m *VariableInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VariableInfo.Unmarshal(m, b)
+}
+func (m *VariableInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VariableInfo.Marshal(b, m, deterministic)
+}
+func (m *VariableInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VariableInfo.Merge(m, src)
+}
+func (m *VariableInfo) XXX_Size() int {
+	return xxx_messageInfo_VariableInfo.Size(m)
+}
+func (m *VariableInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_VariableInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VariableInfo proto.InternalMessageInfo
+
+func (m *VariableInfo) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *VariableInfo) GetFeatures() []string {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
+func (m *VariableInfo) GetCoefficients() uint32 {
+	if m != nil {
+		return m.Coefficients
+	}
+	return 0
+}
+
+func (m *VariableInfo) GetOther() float64 {
+	if m != nil {
+		return m.Other
+	}
+	return 0
+}
+
+func (m *VariableInfo) GetHasOther() bool {
+	if m != nil {
+		return m.HasOther
+	}
+	return false
+}
+
+func (m *VariableInfo) GetHashed() bool {
+	if m != nil {
+		return m.Hashed
+	}
+	return false
+}
+
+type ExplainResponse struct {
+	ModelName string  `protobuf:"bytes,1,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	Variant   string  `protobuf:"bytes,2,opt,name=variant,proto3" json:"variant,omitempty"`
+	Bias      float64 `protobuf:"fixed64,3,opt,name=bias,proto3" json:"bias,omitempty"`
+	// bias plus contributions of all variables
+	Logit float64 `protobuf:"fixed64,4,opt,name=logit,proto3" json:"logit,omitempty"`
+	// prediction of model, as returned by Predict
+	Proba         float64         `protobuf:"fixed64,5,opt,name=proba,proto3" json:"proba,omitempty"`
+	Contributions []*Contribution `protobuf:"bytes,6,rep,name=contributions,proto3" json:"contributions,omitempty"`
+	// probability before calibration, logit transformed by link function,
+	// it's unset for identity and log links which don't predict probability
+	RawProba             float64  `protobuf:"fixed64,7,opt,name=raw_proba,json=rawProba,proto3" json:"raw_proba,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExplainResponse) Reset()         { *m = ExplainResponse{} }
+func (m *ExplainResponse) String() string { return proto.CompactTextString(m) }
+func (*ExplainResponse) ProtoMessage()    {}
+func (*ExplainResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{21}
+}
+
+func (m *ExplainResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExplainResponse.Unmarshal(m, b)
+}
+func (m *ExplainResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExplainResponse.Marshal(b, m, deterministic)
+}
+func (m *ExplainResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExplainResponse.Merge(m, src)
+}
+func (m *ExplainResponse) XXX_Size() int {
+	return xxx_messageInfo_ExplainResponse.Size(m)
+}
+func (m *ExplainResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExplainResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExplainResponse proto.InternalMessageInfo
+
+func (m *ExplainResponse) GetModelName() string {
+	if m != nil {
+		return m.ModelName
+	}
+	return ""
+}
+
+func (m *ExplainResponse) GetVariant() string {
+	if m != nil {
+		return m.Variant
+	}
+	return ""
+}
+
+func (m *ExplainResponse) GetBias() float64 {
+	if m != nil {
+		return m.Bias
+	}
+	return 0
+}
+
+func (m *ExplainResponse) GetLogit() float64 {
+	if m != nil {
+		return m.Logit
+	}
+	return 0
+}
+
+func (m *ExplainResponse) GetProba() float64 {
+	if m != nil {
+		return m.Proba
+	}
+	return 0
+}
+
+func (m *ExplainResponse) GetContributions() []*Contribution {
+	if m != nil {
+		return m.Contributions
+	}
+	return nil
+}
+
+func (m *ExplainResponse) GetRawProba() float64 {
+	if m != nil {
+		return m.RawProba
+	}
+	return 0
+}
+
+// Contribution is the part of logit added by variable
+type Contribution struct {
+	// name as in model file, e.g. geoXXbrowser
+	Variable string `protobuf:"bytes,1,opt,name=variable,proto3" json:"variable,omitempty"`
+	// value of request as in model file, e.g. usX~X8
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// coefficient added to logit
+	Coefficient float64 `protobuf:"fixed64,3,opt,name=coefficient,proto3" json:"coefficient,omitempty"`
+	// value is unseen in training, "fit.other" coefficient
+	// or zero is used instead
+	Fallback bool `protobuf:"varint,4,opt,name=fallback,proto3" json:"fallback,omitempty"`
+	// weight of numeric feature, coefficient is weight times value
+	Weight               float64  `protobuf:"fixed64,5,opt,name=weight,proto3" json:"weight,omitempty"`
+	Hashed               bool     `protobuf:"varint,6,opt,name=hashed,proto3" json:"hashed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Contribution) Reset()         { *m = Contribution{} }
+func (m *Contribution) String() string { return proto.CompactTextString(m) }
+func (*Contribution) ProtoMessage()    {}
+func (*Contribution) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{22}
+}
+
+func (m *Contribution) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Contribution.Unmarshal(m, b)
+}
+func (m *Contribution) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Contribution.Marshal(b, m, deterministic)
+}
+func (m *Contribution) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Contribution.Merge(m, src)
+}
+func (m *Contribution) XXX_Size() int {
+	return xxx_messageInfo_Contribution.Size(m)
+}
+func (m *Contribution) XXX_DiscardUnknown() {
+	xxx_messageInfo_Contribution.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Contribution proto.InternalMessageInfo
+
+func (m *Contribution) GetVariable() string {
+	if m != nil {
+		return m.Variable
+	}
+	return ""
+}
+
+func (m *Contribution) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+func (m *Contribution) GetCoefficient() float64 {
+	if m != nil {
+		return m.Coefficient
+	}
+	return 0
+}
+
+func (m *Contribution) GetFallback() bool {
+	if m != nil {
+		return m.Fallback
+	}
+	return false
+}
+
+func (m *Contribution) GetWeight() float64 {
+	if m != nil {
+		return m.Weight
+	}
+	return 0
+}
+
+func (m *Contribution) GetHashed() bool {
+	if m != nil {
+		return m.Hashed
+	}
+	return false
+}
+
+func init() {
+	proto.RegisterType((*Request)(nil), "inferencer.Request")
+	proto.RegisterMapType((map[string]string)(nil), "inferencer.Request.FeaturesEntry")
+	proto.RegisterType((*Response)(nil), "inferencer.Response")
+	proto.RegisterType((*Prediction)(nil), "inferencer.Prediction")
+	proto.RegisterType((*ClassesResponse)(nil), "inferencer.ClassesResponse")
+	proto.RegisterType((*ClassProba)(nil), "inferencer.ClassProba")
+	proto.RegisterType((*FeedbackRequest)(nil), "inferencer.FeedbackRequest")
+	proto.RegisterType((*FeedbackResponse)(nil), "inferencer.FeedbackResponse")
+	proto.RegisterType((*BatchRequest)(nil), "inferencer.BatchRequest")
+	proto.RegisterType((*BatchResponse)(nil), "inferencer.BatchResponse")
+	proto.RegisterType((*BatchItem)(nil), "inferencer.BatchItem")
+	proto.RegisterType((*StreamRequest)(nil), "inferencer.StreamRequest")
+	proto.RegisterType((*StreamResponse)(nil), "inferencer.StreamResponse")
+	proto.RegisterType((*ReloadRequest)(nil), "inferencer.ReloadRequest")
+	proto.RegisterType((*ReloadResponse)(nil), "inferencer.ReloadResponse")
+	proto.RegisterType((*ModelInfoRequest)(nil), "inferencer.ModelInfoRequest")
+	proto.RegisterType((*ModelInfoResponse)(nil), "inferencer.ModelInfoResponse")
+	proto.RegisterType((*ExportRequest)(nil), "inferencer.ExportRequest")
+	proto.RegisterType((*ExportResponse)(nil), "inferencer.ExportResponse")
+	proto.RegisterType((*CalibrationInfo)(nil), "inferencer.CalibrationInfo")
+	proto.RegisterType((*FeatureInfo)(nil), "inferencer.FeatureInfo")
+	proto.RegisterType((*VariableInfo)(nil), "inferencer.VariableInfo")
+	proto.RegisterType((*ExplainResponse)(nil), "inferencer.ExplainResponse")
+	proto.RegisterType((*Contribution)(nil), "inferencer.Contribution")
+}
+
+func init() { proto.RegisterFile("api.proto", fileDescriptor_00212fb1f9d3bf1c) }
+
+var fileDescriptor_00212fb1f9d3bf1c = []byte{
+	// 1521 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x58, 0x5f, 0x6f, 0xdb, 0x46,
+	0x12, 0x3f, 0x4a, 0x96, 0x25, 0x8d, 0x24, 0xff, 0xd9, 0x24, 0x0e, 0x4f, 0x4e, 0x02, 0x87, 0x2f,
+	0x67, 0xdc, 0xe1, 0xac, 0xfc, 0x01, 0x0e, 0x87, 0xdc, 0xe5, 0x0e, 0x4d, 0x90, 0x14, 0x7e, 0xa8,
+	0x9b, 0x32, 0x80, 0xfb, 0x16, 0x61, 0x49, 0xae, 0x2c, 0xc2, 0x14, 0x57, 0x25, 0x29, 0x3b, 0x4e,
+	0xf3, 0xd4, 0x7e, 0x84, 0x7e, 0x8e, 0xb6, 0xe8, 0x7b, 0x5f, 0xfa, 0xd0, 0x4f, 0xd0, 0xaf, 0x50,
+	0xa0, 0x5f, 0xa3, 0x98, 0xd9, 0x5d, 0x6a, 0xa9, 0x30, 0x8e, 0xdb, 0x27, 0xed, 0xec, 0xcc, 0xfe,
+	0xe6, 0x2f, 0x67, 0x06, 0x82, 0x2e, 0x9f, 0xc7, 0x07, 0xf3, 0x4c, 0x16, 0x92, 0x41, 0x9c, 0x4e,
+	0x44, 0x26, 0xd2, 0x50, 0x64, 0xc3, 0x5b, 0x27, 0x52, 0x9e, 0x24, 0x62, 0xc4, 0xe7, 0xf1, 0x88,
+	0xa7, 0xa9, 0x2c, 0x78, 0x11, 0xcb, 0x34, 0x57, 0x92, 0xde, 0x4f, 0x0d, 0x68, 0xfb, 0xe2, 0x8b,
+	0x85, 0xc8, 0x0b, 0xb6, 0x0b, 0xdd, 0x80, 0xa7, 0xa9, 0xc8, 0xc6, 0x71, 0xe4, 0x3a, 0x7b, 0xce,
+	0xfe, 0x9a, 0xdf, 0x51, 0x17, 0x87, 0x11, 0xbb, 0x09, 0xed, 0x37, 0x32, 0x15, 0xc8, 0x6a, 0x10,
+	0x6b, 0x1d, 0xc9, 0xc3, 0x88, 0x6d, 0x41, 0xf3, 0x44, 0x48, 0xb7, 0xb9, 0xe7, 0xec, 0x77, 0x7d,
+	0x3c, 0x32, 0x17, 0xda, 0x41, 0x26, 0xcf, 0x73, 0x91, 0xb9, 0x6b, 0x24, 0x6a, 0x48, 0x76, 0x1b,
+	0x40, 0xe6, 0xe3, 0x33, 0x91, 0xe5, 0xb1, 0x4c, 0xdd, 0x16, 0x3d, 0xe9, 0xca, 0xfc, 0x58, 0x5d,
+	0xb0, 0x21, 0x74, 0xe6, 0x09, 0x2f, 0x26, 0x32, 0x9b, 0xb9, 0xeb, 0x4a, 0xbf, 0xa1, 0xf1, 0xe9,
+	0x4c, 0x46, 0x22, 0x19, 0xa7, 0x7c, 0x26, 0xdc, 0xb6, 0x7a, 0x4a, 0x37, 0x47, 0x7c, 0x26, 0xd8,
+	0x63, 0xe8, 0x4c, 0x04, 0x2f, 0x16, 0x99, 0xc8, 0xdd, 0xce, 0x5e, 0x73, 0xbf, 0xf7, 0xe0, 0xee,
+	0xc1, 0x32, 0x08, 0x07, 0xda, 0xc5, 0x83, 0xe7, 0x5a, 0xe6, 0x59, 0x5a, 0x64, 0x17, 0x7e, 0xf9,
+	0x64, 0xf8, 0x1f, 0x18, 0x54, 0x58, 0xe8, 0xd5, 0xa9, 0xb8, 0xa0, 0x28, 0x74, 0x7d, 0x3c, 0xb2,
+	0xeb, 0xd0, 0x3a, 0xe3, 0xc9, 0x42, 0x90, 0xfb, 0x5d, 0x5f, 0x11, 0x8f, 0x1a, 0xff, 0x76, 0xbc,
+	0x0b, 0xe8, 0xf8, 0x22, 0x9f, 0xcb, 0x34, 0x17, 0x28, 0x35, 0xcf, 0x64, 0xc0, 0xe9, 0xa5, 0xe3,
+	0x2b, 0x82, 0xdd, 0x01, 0x08, 0x65, 0x3a, 0x89, 0x23, 0xb4, 0x86, 0x00, 0x1c, 0xdf, 0xba, 0x59,
+	0x71, 0xae, 0xb9, 0xea, 0x9c, 0x0b, 0xed, 0x33, 0x9e, 0xc5, 0x3c, 0x2d, 0x28, 0xa0, 0x5d, 0xdf,
+	0x90, 0xde, 0xf7, 0x0e, 0xc0, 0x8b, 0x4c, 0x44, 0x71, 0x88, 0x49, 0x45, 0xed, 0x79, 0x28, 0x33,
+	0x61, 0xb4, 0x13, 0x81, 0xda, 0xe7, 0xa5, 0x8c, 0xd1, 0xbe, 0xbc, 0x61, 0x0c, 0xd6, 0x92, 0x38,
+	0x3d, 0xd5, 0x7a, 0xe9, 0xbc, 0x62, 0xf1, 0xda, 0x07, 0x2c, 0x6e, 0x5d, 0x62, 0xf1, 0x7a, 0xd5,
+	0xe2, 0xb7, 0xb0, 0xf9, 0x34, 0xe1, 0x79, 0x2e, 0xf2, 0x32, 0x66, 0xf7, 0xa0, 0x1d, 0xaa, 0x2b,
+	0xd7, 0xa1, 0xd4, 0xed, 0xd8, 0xa9, 0x23, 0xe9, 0x17, 0x18, 0x46, 0xdf, 0x88, 0xad, 0x68, 0x6f,
+	0x5c, 0xa2, 0xbd, 0x59, 0xd5, 0x7e, 0x04, 0xb0, 0xc4, 0xc3, 0x70, 0x25, 0x3c, 0x10, 0x89, 0x4e,
+	0xb3, 0x22, 0x96, 0x41, 0x6c, 0xd8, 0x41, 0x2c, 0x13, 0xdb, 0xb4, 0x12, 0xeb, 0x1d, 0xc3, 0xe6,
+	0x73, 0x21, 0xa2, 0x80, 0x87, 0xa7, 0xe6, 0x2b, 0xfa, 0x27, 0xb4, 0x33, 0x75, 0x24, 0xd8, 0xde,
+	0x83, 0x6b, 0x35, 0x85, 0xe8, 0x1b, 0x19, 0xc4, 0x0d, 0x93, 0x38, 0x3c, 0x25, 0x6d, 0x1d, 0x5f,
+	0x11, 0xde, 0x97, 0xb0, 0xb5, 0xc4, 0xbd, 0xb4, 0xb4, 0xfe, 0x6c, 0x28, 0x90, 0xb3, 0x98, 0x47,
+	0xbc, 0x10, 0xb9, 0xf9, 0x4a, 0x35, 0xe9, 0xfd, 0x1f, 0xfa, 0x4f, 0x78, 0x11, 0x4e, 0x8d, 0x47,
+	0x23, 0xe8, 0x68, 0x6b, 0x4d, 0x82, 0x6a, 0x5d, 0x2a, 0x85, 0xbc, 0xff, 0xc2, 0x40, 0x03, 0x68,
+	0xd3, 0xff, 0x01, 0xad, 0xb8, 0x10, 0x33, 0xf3, 0xfc, 0x86, 0xfd, 0x9c, 0x24, 0x0f, 0x0b, 0x31,
+	0xf3, 0x95, 0x8c, 0x77, 0x02, 0xdd, 0xf2, 0x8e, 0xdd, 0x43, 0xdd, 0x0a, 0x45, 0x87, 0xf3, 0x7a,
+	0x55, 0xb7, 0xe2, 0xf9, 0xa5, 0x14, 0x56, 0x73, 0x28, 0x23, 0x15, 0x8a, 0x96, 0x4f, 0x67, 0x0c,
+	0x9d, 0xc8, 0x32, 0x99, 0xe9, 0x18, 0x28, 0xc2, 0x3b, 0x82, 0xc1, 0xcb, 0x22, 0x13, 0x7c, 0x66,
+	0x1c, 0xdd, 0x80, 0x46, 0xd9, 0xf9, 0x1a, 0x71, 0x64, 0xa7, 0xb2, 0xf1, 0xe1, 0x54, 0x7a, 0x6f,
+	0x61, 0xc3, 0xe0, 0x69, 0x5b, 0x56, 0x01, 0x6d, 0x6f, 0x1a, 0x7f, 0xc8, 0x9b, 0x66, 0x9d, 0x37,
+	0x6b, 0xb6, 0x37, 0x07, 0x30, 0xf0, 0x45, 0x22, 0x79, 0x64, 0xbc, 0xa9, 0x56, 0x86, 0xb3, 0x52,
+	0x19, 0x5e, 0x00, 0x1b, 0x46, 0x7e, 0x59, 0x60, 0xc4, 0x36, 0x9f, 0x03, 0x11, 0x38, 0x15, 0x50,
+	0x4a, 0x44, 0x63, 0xae, 0xc2, 0xd0, 0xf4, 0x3b, 0xea, 0xe2, 0xa3, 0xe2, 0x03, 0x8d, 0xcb, 0xbb,
+	0x0f, 0x5b, 0x9f, 0x20, 0x71, 0x98, 0x4e, 0xe4, 0x15, 0xcd, 0xfa, 0xb1, 0x09, 0xdb, 0xd6, 0x1b,
+	0x6d, 0xda, 0xe5, 0x8f, 0x30, 0x4a, 0x73, 0x5e, 0x4c, 0x75, 0xf9, 0xd3, 0x19, 0x87, 0x49, 0x38,
+	0x15, 0xe1, 0x69, 0xbe, 0x98, 0x69, 0xc3, 0x4a, 0x9a, 0xed, 0xc0, 0x3a, 0x0e, 0x15, 0x6e, 0xfa,
+	0xa9, 0xa6, 0xaa, 0xbe, 0xb6, 0x56, 0x7c, 0x65, 0xb0, 0x16, 0xc4, 0x3c, 0xa7, 0x86, 0xe6, 0xf8,
+	0x74, 0x66, 0x0f, 0xad, 0xb1, 0xd3, 0xa6, 0xda, 0xbe, 0x69, 0x27, 0x54, 0xcf, 0x14, 0x72, 0xa5,
+	0x14, 0x64, 0xff, 0x82, 0x2e, 0x7d, 0x84, 0x41, 0x52, 0x0e, 0x2b, 0xd7, 0x7e, 0x75, 0xac, 0x99,
+	0xf4, 0x6c, 0x29, 0x8a, 0x06, 0x4c, 0x79, 0x3e, 0x75, 0xbb, 0xca, 0x4b, 0x3c, 0xb3, 0xbb, 0xd0,
+	0xc7, 0xdf, 0x71, 0xb0, 0x08, 0x4f, 0x45, 0x91, 0xbb, 0xb0, 0xe7, 0xec, 0x0f, 0xfc, 0x1e, 0xde,
+	0x3d, 0x51, 0x57, 0xec, 0x31, 0xf4, 0x42, 0x9e, 0xc4, 0x41, 0x46, 0x83, 0xdf, 0xed, 0x51, 0xdd,
+	0xed, 0x56, 0x5a, 0xec, 0x92, 0x4d, 0x3a, 0x6d, 0xf9, 0x72, 0x3a, 0xf4, 0xad, 0xe9, 0xe0, 0x2e,
+	0x3b, 0xf6, 0x60, 0xaf, 0x89, 0x5d, 0x45, 0x93, 0xde, 0x73, 0x18, 0x3c, 0x7b, 0x3d, 0x97, 0x59,
+	0x71, 0xb5, 0x74, 0x63, 0x26, 0x64, 0x9a, 0xc4, 0xa9, 0xd0, 0xfd, 0x4f, 0x53, 0x58, 0x9d, 0x06,
+	0xe7, 0x6a, 0x25, 0x50, 0x16, 0x2f, 0xe2, 0xf4, 0x6b, 0x8b, 0xb7, 0x59, 0x4d, 0xa8, 0xf7, 0xb5,
+	0x03, 0x9b, 0x2b, 0xae, 0xa3, 0xb7, 0xc5, 0xc5, 0xdc, 0xe0, 0xd3, 0x19, 0xef, 0x32, 0x5e, 0x98,
+	0x79, 0x40, 0x67, 0xd6, 0x07, 0xc7, 0x8c, 0x02, 0x87, 0x23, 0x15, 0xe8, 0x21, 0xe9, 0x04, 0x68,
+	0x4a, 0x81, 0x19, 0xd3, 0x63, 0x51, 0x11, 0xe8, 0xe9, 0x5c, 0xc6, 0x69, 0xa1, 0x0a, 0x68, 0xe0,
+	0x6b, 0xca, 0xfb, 0xd9, 0x81, 0x9e, 0x55, 0x27, 0xa8, 0xcd, 0xf2, 0x90, 0xce, 0x88, 0x38, 0x89,
+	0x45, 0x12, 0x99, 0xdd, 0x83, 0x08, 0x94, 0x3c, 0x8d, 0xd3, 0xc8, 0xcc, 0x6d, 0x3c, 0xb3, 0xbf,
+	0xc1, 0xe6, 0x99, 0x0c, 0x79, 0xb0, 0x48, 0x78, 0x76, 0x31, 0xce, 0xe3, 0x37, 0x6a, 0x78, 0x0f,
+	0xfc, 0x8d, 0xe5, 0xf5, 0xcb, 0xf8, 0x0d, 0x0d, 0x06, 0x53, 0x33, 0xad, 0xbd, 0xe6, 0xbe, 0xe3,
+	0x1b, 0x12, 0x0d, 0x3d, 0x17, 0xf1, 0xc9, 0xb4, 0xd0, 0x95, 0xae, 0x29, 0x4c, 0xc0, 0x94, 0xe7,
+	0x63, 0xcd, 0x6b, 0x53, 0xba, 0xba, 0x53, 0x9e, 0x7f, 0x4e, 0x17, 0xde, 0xb7, 0x0e, 0xf4, 0xed,
+	0xca, 0xad, 0x75, 0x64, 0x68, 0x7d, 0x2f, 0x0d, 0xaa, 0x9c, 0x92, 0x66, 0x1e, 0xf4, 0x43, 0x29,
+	0x26, 0x93, 0x38, 0x8c, 0x05, 0x86, 0xa9, 0x49, 0x76, 0x57, 0xee, 0x30, 0x10, 0xb2, 0x98, 0xea,
+	0xc5, 0xd2, 0xf1, 0x15, 0x81, 0x59, 0x46, 0xcb, 0x14, 0xa7, 0x45, 0x86, 0x75, 0xa6, 0x3c, 0xff,
+	0x94, 0x98, 0x3b, 0xb0, 0x8e, 0x5f, 0x83, 0x88, 0xc8, 0x9d, 0x8e, 0xaf, 0x29, 0xef, 0x37, 0x07,
+	0x36, 0x9f, 0xbd, 0x9e, 0x27, 0x3c, 0x4e, 0xaf, 0x5a, 0x63, 0xd6, 0x30, 0x6d, 0x54, 0x87, 0xa9,
+	0xe9, 0x0d, 0x4d, 0xab, 0x37, 0xe0, 0x76, 0x21, 0x4f, 0xe2, 0xc2, 0xd8, 0x4a, 0xc4, 0x72, 0x8a,
+	0xb7, 0xec, 0x29, 0xfe, 0x3f, 0x18, 0x84, 0x32, 0x2d, 0xb2, 0x38, 0x58, 0xd0, 0x76, 0xee, 0xae,
+	0xbf, 0xdb, 0x16, 0x9e, 0x5a, 0x02, 0x7e, 0x55, 0x1c, 0x23, 0x90, 0xf1, 0xf3, 0xb1, 0x42, 0x6e,
+	0x13, 0x72, 0x27, 0xe3, 0xe7, 0xb4, 0xe6, 0x78, 0xdf, 0x39, 0xd0, 0xb7, 0x1f, 0x63, 0x16, 0x4c,
+	0x57, 0xd1, 0x4e, 0x96, 0x74, 0xfd, 0x9a, 0xcb, 0xf6, 0xa0, 0x67, 0xe5, 0x41, 0xbb, 0x69, 0x5f,
+	0x51, 0x66, 0x79, 0x92, 0xe0, 0xc6, 0x42, 0x0e, 0x77, 0xfc, 0x92, 0xb6, 0x2a, 0xaa, 0x55, 0xa9,
+	0xa8, 0xf7, 0xa4, 0xe6, 0xc1, 0x0f, 0x6d, 0x80, 0xc3, 0xd2, 0x71, 0x76, 0x0c, 0x7d, 0xbd, 0xe3,
+	0xaa, 0xb5, 0xad, 0x6e, 0x0a, 0x0f, 0x6b, 0x07, 0xa9, 0xb7, 0xfb, 0xd5, 0x2f, 0xbf, 0x7e, 0xd3,
+	0xb8, 0xe1, 0x6d, 0x8d, 0xce, 0xee, 0x8f, 0xc4, 0x6b, 0x3e, 0x9b, 0x27, 0x62, 0x24, 0xc2, 0xa9,
+	0x7c, 0xe4, 0xfc, 0x9d, 0x1d, 0x41, 0x5b, 0xe3, 0xd6, 0x43, 0x56, 0xd6, 0xd0, 0xe5, 0x96, 0xed,
+	0xed, 0x10, 0xe8, 0x96, 0xd7, 0x43, 0x50, 0xbd, 0x47, 0x23, 0x5e, 0x00, 0x1b, 0x5a, 0x4a, 0x6f,
+	0xb8, 0xf5, 0xb0, 0xbb, 0xef, 0x6c, 0xb7, 0xcb, 0x5d, 0xd8, 0xbb, 0x43, 0xd8, 0xae, 0x77, 0xcd,
+	0xc2, 0x1e, 0xe9, 0xe6, 0x8a, 0x3a, 0x5e, 0x41, 0xc7, 0x2c, 0x86, 0x6c, 0xb7, 0x3a, 0x6a, 0x2a,
+	0x6b, 0xe8, 0xf0, 0x56, 0x3d, 0x53, 0xab, 0xb9, 0x49, 0x6a, 0xb6, 0xbd, 0x3e, 0xaa, 0x99, 0x68,
+	0x2e, 0xe2, 0x4f, 0x60, 0xdb, 0x8e, 0x35, 0x2d, 0x62, 0xcc, 0x7d, 0x67, 0x5f, 0x33, 0x5a, 0xfe,
+	0x5a, 0xc3, 0xd1, 0x2a, 0x6e, 0x91, 0x8a, 0x1d, 0x6f, 0xdb, 0xf6, 0x24, 0x40, 0x11, 0xd4, 0xf3,
+	0x19, 0x30, 0x5b, 0x8f, 0xda, 0x9b, 0x58, 0x05, 0xae, 0xb2, 0x9b, 0x0d, 0x87, 0x75, 0x2c, 0xad,
+	0xea, 0x2f, 0xfb, 0xce, 0x3d, 0x87, 0x71, 0xe8, 0xa9, 0x85, 0x86, 0xd6, 0x87, 0x2a, 0x56, 0x65,
+	0x33, 0x1a, 0x0e, 0xeb, 0x58, 0x75, 0x15, 0xc3, 0xa3, 0x59, 0x9c, 0x8e, 0x32, 0x92, 0x40, 0xab,
+	0x5f, 0x42, 0x5b, 0xb7, 0x8c, 0x2b, 0xa4, 0x76, 0xa5, 0xb9, 0x54, 0xcb, 0x46, 0x28, 0x26, 0x82,
+	0x46, 0xd0, 0xff, 0x58, 0x14, 0xe5, 0xce, 0xc3, 0x2a, 0x99, 0x5b, 0x5d, 0x9f, 0x86, 0xb7, 0xdf,
+	0xc3, 0xad, 0x26, 0x96, 0x6d, 0x2e, 0xcd, 0x57, 0x93, 0xf0, 0x15, 0xf4, 0xd4, 0x40, 0xad, 0x89,
+	0x4e, 0x65, 0x62, 0x0f, 0x87, 0x75, 0x2c, 0x0d, 0xef, 0x12, 0x3c, 0x63, 0x56, 0x74, 0x04, 0x49,
+	0x04, 0xeb, 0xf4, 0x7f, 0xc2, 0xc3, 0xdf, 0x07, 0x00, 0x1a, 0xda, 0xa5, 0xbe, 0x86, 0x10, 0x00,
+	0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// InferencerClient is the client API for Inferencer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type InferencerClient interface {
+	PredictProba(ctx context.Context, in *Request, opts ...grpc.CallOption) (*Response, error)
+	// Predict computes linear score of any generalized linear model
+	// and its prediction transformed by link function of the model,
+	// PredictProba serves only models which predict probability
+	Predict(ctx context.Context, in *Request, opts ...grpc.CallOption) (*Prediction, error)
+	// PredictClasses predicts probability of every class of
+	// multi-class model by softmax of class scores
+	PredictClasses(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ClassesResponse, error)
+	// Feedback reports outcome of request, model is updated by it
+	// if online learning is enabled for the model
+	Feedback(ctx context.Context, in *FeedbackRequest, opts ...grpc.CallOption) (*FeedbackResponse, error)
+	// PredictProbaBatch predicts probabilities for several requests,
+	// responses go in the order of requests
+	PredictProbaBatch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	// PredictProbaStream keeps stream open for many requests,
+	// responses are sent as soon as they are computed and carry
+	// id of request they answer
+	PredictProbaStream(ctx context.Context, opts ...grpc.CallOption) (Inferencer_PredictProbaStreamClient, error)
+	// ReloadModel forces the server to reload model from disk
+	ReloadModel(ctx context.Context, in *ReloadRequest, opts ...grpc.CallOption) (*ReloadResponse, error)
+	// Explain predicts probability like PredictProba and tells
+	// how every variable of model has contributed to it
+	Explain(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ExplainResponse, error)
+	// GetModelInfo describes model which is being served,
+	// so deploy tooling can verify what is live
+	GetModelInfo(ctx context.Context, in *ModelInfoRequest, opts ...grpc.CallOption) (*ModelInfoResponse, error)
+	// ExportModel serializes live model to text format, updates
+	// applied online are included, so model can be archived,
+	// compared or loaded by another replica
+	ExportModel(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*ExportResponse, error)
+}
+
+type inferencerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewInferencerClient(cc *grpc.ClientConn) InferencerClient {
+	return &inferencerClient{cc}
+}
+
+func (c *inferencerClient) PredictProba(ctx context.Context, in *Request, opts ...grpc.CallOption) (*Response, error) {
+	out := new(Response)
+	err := c.cc.Invoke(ctx, "/inferencer.Inferencer/PredictProba", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inferencerClient) Predict(ctx context.Context, in *Request, opts ...grpc.CallOption) (*Prediction, error) {
+	out := new(Prediction)
+	err := c.cc.Invoke(ctx, "/inferencer.Inferencer/Predict", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inferencerClient) PredictClasses(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ClassesResponse, error) {
+	out := new(ClassesResponse)
+	err := c.cc.Invoke(ctx, "/inferencer.Inferencer/PredictClasses", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inferencerClient) Feedback(ctx context.Context, in *FeedbackRequest, opts ...grpc.CallOption) (*FeedbackResponse, error) {
+	out := new(FeedbackResponse)
+	err := c.cc.Invoke(ctx, "/inferencer.Inferencer/Feedback", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inferencerClient) PredictProbaBatch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, "/inferencer.Inferencer/PredictProbaBatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inferencerClient) PredictProbaStream(ctx context.Context, opts ...grpc.CallOption) (Inferencer_PredictProbaStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Inferencer_serviceDesc.Streams[0], "/inferencer.Inferencer/PredictProbaStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &inferencerPredictProbaStreamClient{stream}
+	return x, nil
+}
+
+type Inferencer_PredictProbaStreamClient interface {
+	Send(*StreamRequest) error
+	Recv() (*StreamResponse, error)
+	grpc.ClientStream
+}
+
+type inferencerPredictProbaStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *inferencerPredictProbaStreamClient) Send(m *StreamRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *inferencerPredictProbaStreamClient) Recv() (*StreamResponse, error) {
+	m := new(StreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *inferencerClient) ReloadModel(ctx context.Context, in *ReloadRequest, opts ...grpc.CallOption) (*ReloadResponse, error) {
+	out := new(ReloadResponse)
+	err := c.cc.Invoke(ctx, "/inferencer.Inferencer/ReloadModel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inferencerClient) Explain(ctx context.Context, in *Request, opts ...grpc.CallOption) (*ExplainResponse, error) {
+	out := new(ExplainResponse)
+	err := c.cc.Invoke(ctx, "/inferencer.Inferencer/Explain", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inferencerClient) GetModelInfo(ctx context.Context, in *ModelInfoRequest, opts ...grpc.CallOption) (*ModelInfoResponse, error) {
+	out := new(ModelInfoResponse)
+	err := c.cc.Invoke(ctx, "/inferencer.Inferencer/GetModelInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inferencerClient) ExportModel(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*ExportResponse, error) {
+	out := new(ExportResponse)
+	err := c.cc.Invoke(ctx, "/inferencer.Inferencer/ExportModel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InferencerServer is the server API for Inferencer service.
+type InferencerServer interface {
+	PredictProba(context.Context, *Request) (*Response, error)
+	// Predict computes linear score of any generalized linear model
+	// and its prediction transformed by link function of the model,
+	// PredictProba serves only models which predict probability
+	Predict(context.Context, *Request) (*Prediction, error)
+	// PredictClasses predicts probability of every class of
+	// multi-class model by softmax of class scores
+	PredictClasses(context.Context, *Request) (*ClassesResponse, error)
+	// Feedback reports outcome of request, model is updated by it
+	// if online learning is enabled for the model
+	Feedback(context.Context, *FeedbackRequest) (*FeedbackResponse, error)
+	// PredictProbaBatch predicts probabilities for several requests,
+	// responses go in the order of requests
+	PredictProbaBatch(context.Context, *BatchRequest) (*BatchResponse, error)
+	// PredictProbaStream keeps stream open for many requests,
+	// responses are sent as soon as they are computed and carry
+	// id of request they answer
+	PredictProbaStream(Inferencer_PredictProbaStreamServer) error
+	// ReloadModel forces the server to reload model from disk
+	ReloadModel(context.Context, *ReloadRequest) (*ReloadResponse, error)
+	// Explain predicts probability like PredictProba and tells
+	// how every variable of model has contributed to it
+	Explain(context.Context, *Request) (*ExplainResponse, error)
+	// GetModelInfo describes model which is being served,
+	// so deploy tooling can verify what is live
+	GetModelInfo(context.Context, *ModelInfoRequest) (*ModelInfoResponse, error)
+	// ExportModel serializes live model to text format, updates
+	// applied online are included, so model can be archived,
+	// compared or loaded by another replica
+	ExportModel(context.Context, *ExportRequest) (*ExportResponse, error)
+}
+
+// UnimplementedInferencerServer can be embedded to have forward compatible implementations.
+type UnimplementedInferencerServer struct {
+}
+
+func (*UnimplementedInferencerServer) PredictProba(ctx context.Context, req *Request) (*Response, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PredictProba not implemented")
+}
+func (*UnimplementedInferencerServer) Predict(ctx context.Context, req *Request) (*Prediction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Predict not implemented")
+}
+func (*UnimplementedInferencerServer) PredictClasses(ctx context.Context, req *Request) (*ClassesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PredictClasses not implemented")
+}
+func (*UnimplementedInferencerServer) Feedback(ctx context.Context, req *FeedbackRequest) (*FeedbackResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Feedback not implemented")
+}
+func (*UnimplementedInferencerServer) PredictProbaBatch(ctx context.Context, req *BatchRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PredictProbaBatch not implemented")
+}
+func (*UnimplementedInferencerServer) PredictProbaStream(srv Inferencer_PredictProbaStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method PredictProbaStream not implemented")
+}
+func (*UnimplementedInferencerServer) ReloadModel(ctx context.Context, req *ReloadRequest) (*ReloadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReloadModel not implemented")
+}
+func (*UnimplementedInferencerServer) Explain(ctx context.Context, req *Request) (*ExplainResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Explain not implemented")
+}
+func (*UnimplementedInferencerServer) GetModelInfo(ctx context.Context, req *ModelInfoRequest) (*ModelInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetModelInfo not implemented")
+}
+func (*UnimplementedInferencerServer) ExportModel(ctx context.Context, req *ExportRequest) (*ExportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportModel not implemented")
+}
+
+func RegisterInferencerServer(s *grpc.Server, srv InferencerServer) {
+	s.RegisterService(&_Inferencer_serviceDesc, srv)
+}
+
+func _Inferencer_PredictProba_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InferencerServer).PredictProba(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inferencer.Inferencer/PredictProba",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InferencerServer).PredictProba(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Inferencer_Predict_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InferencerServer).Predict(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inferencer.Inferencer/Predict",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InferencerServer).Predict(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Inferencer_PredictClasses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InferencerServer).PredictClasses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inferencer.Inferencer/PredictClasses",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InferencerServer).PredictClasses(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Inferencer_Feedback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FeedbackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InferencerServer).Feedback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inferencer.Inferencer/Feedback",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InferencerServer).Feedback(ctx, req.(*FeedbackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Inferencer_PredictProbaBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InferencerServer).PredictProbaBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inferencer.Inferencer/PredictProbaBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InferencerServer).PredictProbaBatch(ctx, req.(*BatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Inferencer_PredictProbaStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(InferencerServer).PredictProbaStream(&inferencerPredictProbaStreamServer{stream})
+}
+
+type Inferencer_PredictProbaStreamServer interface {
+	Send(*StreamResponse) error
+	Recv() (*StreamRequest, error)
+	grpc.ServerStream
+}
+
+type inferencerPredictProbaStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *inferencerPredictProbaStreamServer) Send(m *StreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *inferencerPredictProbaStreamServer) Recv() (*StreamRequest, error) {
+	m := new(StreamRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Inferencer_ReloadModel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InferencerServer).ReloadModel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inferencer.Inferencer/ReloadModel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InferencerServer).ReloadModel(ctx, req.(*ReloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Inferencer_Explain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Request)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InferencerServer).Explain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inferencer.Inferencer/Explain",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InferencerServer).Explain(ctx, req.(*Request))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Inferencer_GetModelInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModelInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InferencerServer).GetModelInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inferencer.Inferencer/GetModelInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InferencerServer).GetModelInfo(ctx, req.(*ModelInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Inferencer_ExportModel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InferencerServer).ExportModel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/inferencer.Inferencer/ExportModel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InferencerServer).ExportModel(ctx, req.(*ExportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Inferencer_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "inferencer.Inferencer",
+	HandlerType: (*InferencerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PredictProba",
+			Handler:    _Inferencer_PredictProba_Handler,
+		},
+		{
+			MethodName: "Predict",
+			Handler:    _Inferencer_Predict_Handler,
+		},
+		{
+			MethodName: "PredictClasses",
+			Handler:    _Inferencer_PredictClasses_Handler,
+		},
+		{
+			MethodName: "Feedback",
+			Handler:    _Inferencer_Feedback_Handler,
+		},
+		{
+			MethodName: "PredictProbaBatch",
+			Handler:    _Inferencer_PredictProbaBatch_Handler,
+		},
+		{
+			MethodName: "ReloadModel",
+			Handler:    _Inferencer_ReloadModel_Handler,
+		},
+		{
+			MethodName: "Explain",
+			Handler:    _Inferencer_Explain_Handler,
+		},
+		{
+			MethodName: "GetModelInfo",
+			Handler:    _Inferencer_GetModelInfo_Handler,
+		},
+		{
+			MethodName: "ExportModel",
+			Handler:    _Inferencer_ExportModel_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "PredictProbaStream",
+			Handler:       _Inferencer_PredictProbaStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api.proto",
 }
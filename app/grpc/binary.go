@@ -0,0 +1,535 @@
+package serving
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"sort"
+)
+
+// Binary model format is the compact alternative to text one,
+// it's loaded without parsing.
+// All numbers are little endian, strings are prefixed by uint32
+// length. File starts with header
+//
+//	magic    [8]byte "GOINFERM"
+//	version  uint32
+//	checksum uint32 CRC-32 (IEEE) of body
+//	length   uint64 length of body
+//
+// Body of version 4 is
+//
+//	schema       features: name, kind, buckets
+//	bias         float64
+//	weights      numeric features with weights
+//	vocabularies values of each feature in token order
+//	variables    features, fit.other and coefficients by tokens
+//	hashing      optional hash function, buckets, variables, weights
+//	counts       optional counts of bias, fit.other and coefficients
+//	link         name of link function
+//	form         whether bias is declared and variables
+//	             with fit.other written per feature
+//
+// Features are referenced by position in schema of file,
+// they are mapped to base schema by name when loading.
+// Version 1 has no counts, version 2 has no link and version 3
+// has no form, models of all of them are still loaded, their
+// link is logistic, bias is declared and fit.other is single
+const (
+	binaryMagic   = "GOINFERM"
+	binaryVersion = 4
+
+	binaryHeaderLen = len(binaryMagic) + 4 + 4 + 8
+)
+
+var kindCodes = map[string]uint8{
+	"":              0,
+	kindCategorical: 1,
+	kindNumeric:     2,
+}
+
+// isBinaryModel tells if data starts with magic of binary model
+func isBinaryModel(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(binaryMagic))
+}
+
+// WriteBinary encodes model in binary format. Variables and values
+// are sorted, so the same model always produces the same file
+func WriteBinary(w io.Writer, m *Model) error {
+	if m.classes != nil {
+		return errors.New("multi-class model has no binary format")
+	}
+	var b binWriter
+
+	b.u32(uint32(m.schema.Len()))
+	for _, spec := range m.schema.features {
+		b.str(string(spec.name))
+		b.u8(kindCodes[spec.kind])
+		b.u32(uint32(len(spec.buckets)))
+		for _, x := range spec.buckets {
+			b.f64(x)
+		}
+	}
+
+	b.f64(m.bias)
+
+	numeric := make([]FeatureName, 0, len(m.weights))
+	for f := range m.weights {
+		numeric = append(numeric, f)
+	}
+	sort.Slice(numeric, func(i, j int) bool { return numeric[i] < numeric[j] })
+	b.u32(uint32(len(numeric)))
+	for _, f := range numeric {
+		b.u8(uint8(f))
+		b.f64(m.weights[f])
+	}
+
+	for f := 0; f < m.schema.Len(); f++ {
+		vals := make([]string, m.values.uniqs[FeatureName(f)])
+		for val, token := range m.values.store[FeatureName(f)] {
+			vals[token] = val
+		}
+		b.u32(uint32(len(vals)))
+		for _, val := range vals {
+			b.str(val)
+		}
+	}
+
+	variables := make([]Variable, 0, len(m.variables))
+	for v := range m.variables {
+		variables = append(variables, v)
+	}
+	sort.Slice(variables, func(i, j int) bool {
+		return variables[i].features < variables[j].features
+	})
+	b.u32(uint32(len(variables)))
+	for _, v := range variables {
+		b.variable(v)
+
+		other, ok := m.other[v]
+		b.bool(ok)
+		b.f64(other)
+
+		values := make([]Value, 0, len(m.coef[v]))
+		for value := range m.coef[v] {
+			values = append(values, value)
+		}
+		sort.Slice(values, func(i, j int) bool {
+			return values[i].tokens < values[j].tokens
+		})
+		b.u32(uint32(len(values)))
+		for _, value := range values {
+			for i := 0; i < int(value.size); i++ {
+				b.u32(value.token(i))
+			}
+			b.f64(m.coef[v][value])
+		}
+	}
+
+	b.bool(m.hashing != nil)
+	if h := m.hashing; h != nil {
+		b.str(h.name)
+		b.u32(uint32(len(h.weights)))
+		b.u32(uint32(len(h.variables)))
+		for _, v := range h.variables {
+			b.variable(v)
+		}
+
+		var nonzero uint32
+		for _, w := range h.weights {
+			if w != 0 {
+				nonzero++
+			}
+		}
+		b.u32(nonzero)
+		for i, w := range h.weights {
+			if w != 0 {
+				b.u32(uint32(i))
+				b.f64(w)
+			}
+		}
+	}
+
+	b.bool(m.biasCount != nil)
+	if m.biasCount != nil {
+		b.f64(*m.biasCount)
+	}
+	var others, counted []Variable
+	for _, v := range variables {
+		if _, ok := m.otherCounts[v]; ok {
+			others = append(others, v)
+		}
+		if len(m.counts[v]) > 0 {
+			counted = append(counted, v)
+		}
+	}
+	b.u32(uint32(len(others)))
+	for _, v := range others {
+		b.variable(v)
+		b.f64(m.otherCounts[v])
+	}
+	b.u32(uint32(len(counted)))
+	for _, v := range counted {
+		b.variable(v)
+		values := make([]Value, 0, len(m.counts[v]))
+		for value := range m.counts[v] {
+			values = append(values, value)
+		}
+		sort.Slice(values, func(i, j int) bool {
+			return values[i].tokens < values[j].tokens
+		})
+		b.u32(uint32(len(values)))
+		for _, value := range values {
+			for i := 0; i < int(value.size); i++ {
+				b.u32(value.token(i))
+			}
+			b.f64(m.counts[v][value])
+		}
+	}
+
+	b.str(m.link)
+
+	b.bool(m.hasBias)
+	var split []Variable
+	for _, v := range variables {
+		if m.splitOther[v] {
+			split = append(split, v)
+		}
+	}
+	b.u32(uint32(len(split)))
+	for _, v := range split {
+		b.variable(v)
+	}
+
+	header := make([]byte, binaryHeaderLen)
+	copy(header, binaryMagic)
+	binary.LittleEndian.PutUint32(header[8:], binaryVersion)
+	binary.LittleEndian.PutUint32(header[12:], crc32.ChecksumIEEE(b.buf))
+	binary.LittleEndian.PutUint64(header[16:], uint64(len(b.buf)))
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(b.buf)
+	return err
+}
+
+// parseBinary decodes binary model, features of file
+// are added to schema the same way as in text format
+func parseBinary(data []byte, schema *Schema) (*Model, error) {
+	if len(data) < binaryHeaderLen || !isBinaryModel(data) {
+		return nil, errors.New("not a binary model")
+	}
+	version := binary.LittleEndian.Uint32(data[8:])
+	if version < 1 || version > binaryVersion {
+		return nil, fmt.Errorf("unsupported binary model version %d", version)
+	}
+	checksum := binary.LittleEndian.Uint32(data[12:])
+	length := binary.LittleEndian.Uint64(data[16:])
+	body := data[binaryHeaderLen:]
+	if uint64(len(body)) != length {
+		return nil, fmt.Errorf("binary model is truncated: %d of %d bytes", len(body), length)
+	}
+	if crc32.ChecksumIEEE(body) != checksum {
+		return nil, errors.New("binary model checksum mismatch")
+	}
+
+	r := &binReader{buf: body}
+
+	// features maps positions in file to schema
+	features := make([]FeatureName, r.count(9))
+	for i := range features {
+		if r.err != nil {
+			break
+		}
+		name := r.str()
+		kind := r.u8()
+		buckets := make([]float64, r.count(8))
+		for j := range buckets {
+			buckets[j] = r.f64()
+		}
+		if r.err != nil {
+			break
+		}
+		if kind > kindCodes[kindNumeric] {
+			return nil, fmt.Errorf("binary model: unknown kind %d of %s", kind, name)
+		}
+
+		f, err := schema.resolve(FeatureNameString(name))
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(buckets) > 0:
+			err = schema.setBuckets(f, buckets)
+		case kind == kindCodes[kindNumeric]:
+			err = schema.setNumeric(f)
+		}
+		if err != nil {
+			return nil, err
+		}
+		features[i] = f
+	}
+	feature := func() FeatureName {
+		i := int(r.u8())
+		if i >= len(features) {
+			r.fail("feature %d is out of schema", i)
+			return 0
+		}
+		return features[i]
+	}
+	variable := func() Variable {
+		types := make([]FeatureName, r.u8())
+		for i := range types {
+			types[i] = feature()
+		}
+		return newVariable(types...)
+	}
+
+	valuestore := NewKVStore()
+	value := func(v Variable) Value {
+		tokens := make([]uint32, v.size)
+		for i := range tokens {
+			tokens[i] = r.u32()
+			if tokens[i] >= valuestore.uniqs[v.feature(i)] {
+				r.fail("token %d is out of vocabulary", tokens[i])
+			}
+		}
+		return newValue(tokens...)
+	}
+
+	bias := r.f64()
+
+	weights := make(map[FeatureName]float64)
+	for n := r.count(9); n > 0; n-- {
+		f := feature()
+		weights[f] = r.f64()
+	}
+
+	for i := range features {
+		n := uint32(r.count(4))
+		for token := uint32(0); token < n; token++ {
+			if t, _ := valuestore.Set(features[i], r.str()); t != token {
+				r.fail("duplicate value in vocabulary of %s", schema.Name(features[i]))
+			}
+		}
+	}
+
+	variables := make(VariableSet)
+	coefstore := make(CoeffStore)
+	otherstore := make(OtherStore)
+	for n := r.count(2); n > 0 && r.err == nil; n-- {
+		v := variable()
+		variables[v] = true
+		hasOther := r.bool()
+		other := r.f64()
+		if hasOther {
+			otherstore[v] = other
+		}
+
+		inner := make(ValueStore)
+		for k := r.count(8); k > 0 && r.err == nil; k-- {
+			inner[value(v)] = r.f64()
+		}
+		if len(inner) > 0 {
+			coefstore[v] = inner
+		}
+	}
+
+	var hashing *Hashing
+	if r.bool() {
+		name := r.str()
+		seed, ok := hashFuncs[name]
+		if !ok && r.err == nil {
+			return nil, fmt.Errorf("unknown hash function %q", name)
+		}
+		hashing = &Hashing{name: name, seed: seed}
+		buckets := r.u32()
+		if buckets == 0 || buckets > maxHashBuckets {
+			r.fail("hash_buckets must be in range [1, %d]", maxHashBuckets)
+			buckets = 0
+		}
+		for n := r.count(1); n > 0 && r.err == nil; n-- {
+			v := variable()
+			hashing.variables = append(hashing.variables, v)
+			hashing.prefixes = append(hashing.prefixes, v.fileName(schema)+"=")
+		}
+		hashing.weights = make([]float64, buckets)
+		for n := r.count(12); n > 0 && r.err == nil; n-- {
+			i := r.u32()
+			if i >= buckets {
+				r.fail("bucket %d is out of range", i)
+				break
+			}
+			hashing.weights[i] = r.f64()
+		}
+	}
+
+	counts := make(CoeffStore)
+	othercounts := make(OtherStore)
+	var biascount *float64
+	if version >= 2 {
+		if r.bool() {
+			n := r.f64()
+			biascount = &n
+		}
+		for n := r.count(2); n > 0 && r.err == nil; n-- {
+			v := variable()
+			othercounts[v] = r.f64()
+		}
+		for n := r.count(2); n > 0 && r.err == nil; n-- {
+			v := variable()
+			inner := make(ValueStore)
+			for k := r.count(8); k > 0 && r.err == nil; k-- {
+				inner[value(v)] = r.f64()
+			}
+			counts[v] = inner
+		}
+	}
+
+	link := defaultLink
+	if version >= 3 {
+		link = r.str()
+		if _, ok := linkFuncs[link]; !ok && r.err == nil {
+			return nil, fmt.Errorf("unknown link function %q", link)
+		}
+	}
+
+	hasBias := true
+	splitOther := make(VariableSet)
+	if version >= 4 {
+		hasBias = r.bool()
+		for n := r.count(1); n > 0 && r.err == nil; n-- {
+			splitOther[variable()] = true
+		}
+	}
+
+	if r.err == nil && len(r.buf) > 0 {
+		r.fail("%d unexpected bytes at the end", len(r.buf))
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	m := newModel(schema, variables, *valuestore, coefstore, otherstore)
+	m.bias = bias
+	m.hasBias = hasBias
+	m.splitOther = splitOther
+	m.weights = weights
+	m.hashing = hashing
+	m.link = link
+	m.counts = counts
+	m.otherCounts = othercounts
+	m.biasCount = biascount
+	m.compiled = compile(m)
+	return m, nil
+}
+
+type binWriter struct {
+	buf []byte
+}
+
+func (w *binWriter) u8(v uint8) {
+	w.buf = append(w.buf, v)
+}
+
+func (w *binWriter) bool(v bool) {
+	if v {
+		w.u8(1)
+	} else {
+		w.u8(0)
+	}
+}
+
+func (w *binWriter) u32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	w.buf = append(w.buf, b[:]...)
+}
+
+func (w *binWriter) f64(v float64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+	w.buf = append(w.buf, b[:]...)
+}
+
+func (w *binWriter) str(s string) {
+	w.u32(uint32(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *binWriter) variable(v Variable) {
+	w.u8(v.size)
+	w.buf = append(w.buf, v.features...)
+}
+
+// binReader decodes body of binary model, the first problem
+// is kept in err and the rest of reads return zero values
+type binReader struct {
+	buf []byte
+	err error
+}
+
+func (r *binReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf("binary model: "+format, args...)
+	}
+	r.buf = nil
+}
+
+func (r *binReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.buf) < n {
+		r.fail("unexpected end of data")
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *binReader) u8() uint8 {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *binReader) bool() bool {
+	return r.u8() != 0
+}
+
+func (r *binReader) u32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *binReader) f64() float64 {
+	if b := r.next(8); b != nil {
+		return math.Float64frombits(binary.LittleEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (r *binReader) str() string {
+	return string(r.next(int(r.u32())))
+}
+
+// count reads number of items which take at least size bytes each,
+// so corrupted counts don't cause huge allocations
+func (r *binReader) count(size int) int {
+	n := int(r.u32())
+	if n*size > len(r.buf) {
+		r.fail("count %d exceeds data", n)
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,173 @@
+package serving
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goinfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	text := filepath.Join(dir, "trained.model")
+	writeModel(t, text, strings.Join([]string{
+		"0:bias:-1",
+		"1:geo=us:0.5",
+		"2:geo=fit.other:-0.25",
+		"3:segment=sport:1",
+		"4:geoXXbrowserXXos_version=usX~X8X~Xmac10.12:2",
+		"5:buckets=hour:6,12",
+		"6:hour=1:0.75",
+		"7:bid_floor:0.125",
+	}, "\n"))
+	bin := filepath.Join(dir, "trained.bin")
+
+	if _, err := ConvertModel(text, bin, DefaultSchema()); err != nil {
+		t.Fatal(err)
+	}
+	// converting binary model produces the same file
+	again := filepath.Join(dir, "again.bin")
+	if _, err := ConvertModel(bin, again, DefaultSchema()); err != nil {
+		t.Fatal(err)
+	}
+	b1, _ := ioutil.ReadFile(bin)
+	b2, _ := ioutil.ReadFile(again)
+	if !bytes.Equal(b1, b2) {
+		t.Errorf("binary model isn't stable")
+	}
+
+	fromText, err := loadModel("text", text, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromBin, err := loadModel("bin", bin, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, req := range []*pb.Request{
+		{Geo: "us", Browser: 8, OsVersion: "mac10.12"},
+		{Geo: "de", Features: map[string]string{"segment": "sport", "hour": "7", "bid_floor": "4"}},
+		{Geo: "us", Features: map[string]string{"hour": "13"}},
+	} {
+		s1, _ := fromText.score(req)
+		s2, _ := fromBin.score(req)
+		if s1 != s2 {
+			t.Errorf("%v: binary score %v != %v", req, s2, s1)
+		}
+	}
+}
+
+func TestBinaryHashedRoundTrip(t *testing.T) {
+	m, err := parse(&[]string{
+		"#hash=fnv1a32",
+		"#hash_buckets=64",
+		"#variables=geo,geoXXsegment",
+		"0:h=3:0.5",
+		"1:h=60:-1",
+	}, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBinary(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := parseBinary(buf.Bytes(), DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := decoded.hashing
+	if h == nil || h.name != "fnv1a32" || len(h.weights) != 64 || len(h.variables) != 2 {
+		t.Fatalf("unexpected hashing %+v", h)
+	}
+	if h.weights[3] != 0.5 || h.weights[60] != -1 {
+		t.Errorf("unexpected weights %v %v", h.weights[3], h.weights[60])
+	}
+}
+
+func TestBinaryErrors(t *testing.T) {
+	m, err := parse(&[]string{"0:geo=us:1", "1:segment=sport:2"}, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteBinary(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	corrupt := func(fn func(b []byte) []byte) []byte {
+		b := append([]byte(nil), data...)
+		return fn(b)
+	}
+
+	strict := DefaultSchema()
+	strict.strict = true
+
+	cases := []struct {
+		data   []byte
+		schema *Schema
+		reason string
+	}{
+		{corrupt(func(b []byte) []byte { b[8] = 9; return b }), DefaultSchema(), "unsupported binary model version"},
+		{corrupt(func(b []byte) []byte { return b[:len(b)-1] }), DefaultSchema(), "truncated"},
+		{corrupt(func(b []byte) []byte { b[len(b)-1] ^= 1; return b }), DefaultSchema(), "checksum mismatch"},
+		{data, strict, "unknown feature"},
+	}
+	for _, c := range cases {
+		_, err := parseBinary(c.data, c.schema)
+		if err == nil || !strings.Contains(err.Error(), c.reason) {
+			t.Errorf("expected %q, got %v", c.reason, err)
+		}
+	}
+}
+
+func TestBinaryKeepsTextForm(t *testing.T) {
+	sources := []string{
+		countedModel,
+		// model without bias, with fallbacks of interactions in both forms
+		`0:geo=us:1
+1:geoXXbrowser=usX~X8:0.25
+2:geoXXbrowser=fit.otherX~Xfit.other:0.1
+3:geoXXbrowserXXos_version=usX~X8X~Xmac:0.5
+4:geoXXbrowserXXos_version=fit.other:-0.5`,
+	}
+	for _, source := range sources {
+		lines := strings.Split(source, "\n")
+		m, err := parse(&lines, DefaultSchema())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var bin bytes.Buffer
+		if err := WriteBinary(&bin, m); err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := parseBinary(bin.Bytes(), DefaultSchema())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var expected, got bytes.Buffer
+		if err := WriteText(&expected, m); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteText(&got, decoded); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != expected.String() {
+			t.Errorf("text of binary model differs:\n%s\n%s", got.String(), expected.String())
+		}
+	}
+}
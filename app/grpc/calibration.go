@@ -0,0 +1,191 @@
+package serving
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	pb "github.com/go-code/goinfer/api"
+)
+
+// Calibration maps score of model to probability.
+// Without calibration probability is Sigmoid of score
+//
+// Config format is
+//
+//	models:
+//	 - name: <model name>
+//	   path: <path to model file>
+//	   calibration:
+//	     type: <downsampling, platt or isotonic>
+//	     rate: <fraction of negatives kept in training for downsampling>
+//	     a: <slope for platt>
+//	     b: <intercept for platt>
+//	     table: <path to mapping file for isotonic>
+//
+// Model declared with legacy "model" key is calibrated
+// by "calibration" key at the top of config
+type Calibration interface {
+	Proba(score float64) float64
+	Info() *pb.CalibrationInfo
+}
+
+// noCalibration is plain Sigmoid
+type noCalibration struct{}
+
+func (noCalibration) Proba(score float64) float64 {
+	return Sigmoid(score)
+}
+
+func (noCalibration) Info() *pb.CalibrationInfo {
+	return &pb.CalibrationInfo{Type: "none"}
+}
+
+// downsampling corrects probability of model trained on
+// negatives sampled at rate w: p' = p / (p + (1-p)/w),
+// it's the same as adding log(w) to score
+type downsampling struct {
+	rate float64
+}
+
+func (c downsampling) Proba(score float64) float64 {
+	return Sigmoid(score + math.Log(c.rate))
+}
+
+func (c downsampling) Info() *pb.CalibrationInfo {
+	return &pb.CalibrationInfo{Type: "downsampling", Rate: c.rate}
+}
+
+// platt scales score: p = Sigmoid(a*score + b)
+type platt struct {
+	a, b float64
+}
+
+func (c platt) Proba(score float64) float64 {
+	return Sigmoid(c.a*score + c.b)
+}
+
+func (c platt) Info() *pb.CalibrationInfo {
+	return &pb.CalibrationInfo{Type: "platt", A: c.a, B: c.b}
+}
+
+// isotonic maps Sigmoid of score by piecewise linear table,
+// probabilities out of table range are clamped to its ends.
+//
+// Each line of table file is
+//
+//	<probability>,<calibrated probability>
+//
+// probabilities must increase and calibrated ones must not decrease
+type isotonic struct {
+	path string
+	x, y []float64
+}
+
+func (c *isotonic) Proba(score float64) float64 {
+	p := Sigmoid(score)
+	i := sort.SearchFloat64s(c.x, p)
+	switch {
+	case i == 0:
+		return c.y[0]
+	case i == len(c.x):
+		return c.y[len(c.y)-1]
+	}
+	t := (p - c.x[i-1]) / (c.x[i] - c.x[i-1])
+	return c.y[i-1] + t*(c.y[i]-c.y[i-1])
+}
+
+func (c *isotonic) Info() *pb.CalibrationInfo {
+	return &pb.CalibrationInfo{
+		Type:   "isotonic",
+		Table:  c.path,
+		Points: uint32(len(c.x)),
+	}
+}
+
+// loadIsotonic reads isotonic table file
+func loadIsotonic(path string) (*isotonic, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	c := &isotonic{path: path}
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.Split(line, ",")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected <probability>,<calibrated probability>", path, n)
+		}
+		x, errx := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		y, erry := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if errx != nil || erry != nil || !(x >= 0 && x <= 1) || !(y >= 0 && y <= 1) {
+			return nil, fmt.Errorf("%s:%d: probabilities must be in range [0, 1]", path, n)
+		}
+		if k := len(c.x); k > 0 && (x <= c.x[k-1] || y < c.y[k-1]) {
+			return nil, fmt.Errorf("%s:%d: table must be increasing", path, n)
+		}
+		c.x = append(c.x, x)
+		c.y = append(c.y, y)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(c.x) < 2 {
+		return nil, fmt.Errorf("%s: table must have at least 2 points", path)
+	}
+	return c, nil
+}
+
+// calibrationFromConfig reads calibration section,
+// nil section means no calibration
+func calibrationFromConfig(section interface{}) (Calibration, error) {
+	if section == nil {
+		return noCalibration{}, nil
+	}
+	entry, ok := asYaml(section)
+	if !ok {
+		return nil, fmt.Errorf("calibration: expected type")
+	}
+
+	kind, _ := entry["type"].(string)
+	switch kind {
+	case "none":
+		return noCalibration{}, nil
+	case "downsampling":
+		rate, ok := asFloat(entry["rate"])
+		if !ok || rate <= 0 || rate > 1 {
+			return nil, fmt.Errorf("calibration: rate must be in range (0, 1]")
+		}
+		return downsampling{rate: rate}, nil
+	case "platt":
+		a, aok := asFloat(entry["a"])
+		b, bok := asFloat(entry["b"])
+		if !aok || !bok {
+			return nil, fmt.Errorf("calibration: a and b must be numbers")
+		}
+		return platt{a: a, b: b}, nil
+	case "isotonic":
+		path, _ := entry["table"].(string)
+		if path == "" {
+			return nil, fmt.Errorf("calibration: expected table")
+		}
+		c, err := loadIsotonic(path)
+		if err != nil {
+			return nil, fmt.Errorf("calibration: %v", err)
+		}
+		return c, nil
+	default:
+		return nil, fmt.Errorf("calibration: unknown type %q", kind)
+	}
+}
@@ -0,0 +1,222 @@
+package serving
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+	"gopkg.in/yaml.v2"
+)
+
+func writeTable(t *testing.T, content string) string {
+	t.Helper()
+	file, err := ioutil.TempFile("", "goinfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	writeModel(t, file.Name(), content)
+	return file.Name()
+}
+
+func TestDownsampling(t *testing.T) {
+	c := downsampling{rate: 0.1}
+	for _, score := range []float64{-3, 0, 2.5} {
+		p := Sigmoid(score)
+		expected := p / (p + (1-p)/c.rate)
+		if got := c.Proba(score); math.Abs(got-expected) > 1e-12 {
+			t.Errorf("score %v: proba %v != %v", score, got, expected)
+		}
+	}
+	if got := (downsampling{rate: 1}).Proba(1); got != Sigmoid(1) {
+		t.Errorf("rate 1 changed proba: %v", got)
+	}
+}
+
+func TestPlatt(t *testing.T) {
+	c := platt{a: 2, b: -1}
+	if got := c.Proba(0.75); got != Sigmoid(0.5) {
+		t.Errorf("proba %v != %v", got, Sigmoid(0.5))
+	}
+}
+
+func TestIsotonic(t *testing.T) {
+	path := writeTable(t, "# probability,calibrated\n0.2,0.1\n0.5,0.3\n\n0.8,0.9\n")
+	defer os.Remove(path)
+
+	c, err := loadIsotonic(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logit := func(p float64) float64 { return math.Log(p / (1 - p)) }
+	cases := []struct {
+		p, expected float64
+	}{
+		{0.1, 0.1},
+		{0.2, 0.1},
+		{0.35, 0.2},
+		{0.5, 0.3},
+		{0.7, 0.7},
+		{0.95, 0.9},
+	}
+	for _, cs := range cases {
+		if got := c.Proba(logit(cs.p)); math.Abs(got-cs.expected) > 1e-9 {
+			t.Errorf("p %v: proba %v != %v", cs.p, got, cs.expected)
+		}
+	}
+
+	if info := c.Info(); info.Type != "isotonic" || info.Table != path || info.Points != 3 {
+		t.Errorf("unexpected info %v", info)
+	}
+}
+
+func TestIsotonicErrors(t *testing.T) {
+	cases := []struct {
+		table  string
+		reason string
+	}{
+		{"0.1,0.1", "at least 2 points"},
+		{"0.1;0.1\n0.2,0.2", ":1: expected"},
+		{"0.1,0.1\n0.2,x", ":2: probabilities must be in range"},
+		{"0.1,0.1\n1.5,0.2", ":2: probabilities must be in range"},
+		{"0.2,0.1\n0.1,0.2", ":2: table must be increasing"},
+		{"0.1,0.2\n0.2,0.1", ":2: table must be increasing"},
+	}
+	for _, c := range cases {
+		path := writeTable(t, c.table)
+		_, err := loadIsotonic(path)
+		os.Remove(path)
+		if err == nil || !strings.Contains(err.Error(), c.reason) {
+			t.Errorf("%q: expected %q, got %v", c.table, c.reason, err)
+		}
+	}
+}
+
+func TestCalibrationFromConfig(t *testing.T) {
+	data := `
+model: legacy.model
+calibration:
+  type: downsampling
+  rate: 0.25
+models:
+ - name: platt
+   path: platt.model
+   calibration:
+     type: platt
+     a: 1
+     b: -0.5
+ - name: plain
+   path: plain.model
+`
+	config := make(Yaml)
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	calibrations, err := calibrationsFromConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := calibrations[DefaultModelName]; c != (downsampling{rate: 0.25}) {
+		t.Errorf("legacy model calibration %v", c)
+	}
+	if c := calibrations["platt"]; c != (platt{a: 1, b: -0.5}) {
+		t.Errorf("platt calibration %v", c)
+	}
+	if c := calibrations["plain"]; c != (noCalibration{}) {
+		t.Errorf("plain calibration %v", c)
+	}
+
+	errors := []struct {
+		section string
+		reason  string
+	}{
+		{"type: sigmoid", `unknown type "sigmoid"`},
+		{"type: downsampling", "rate must be in range"},
+		{"{type: downsampling, rate: 0}", "rate must be in range"},
+		{"{type: downsampling, rate: 2}", "rate must be in range"},
+		{"{type: platt, a: 1}", "a and b must be numbers"},
+		{"type: isotonic", "expected table"},
+		{"{type: isotonic, table: /nonexistent}", "calibration: open /nonexistent"},
+	}
+	for _, e := range errors {
+		var section interface{}
+		if err := yaml.Unmarshal([]byte(e.section), &section); err != nil {
+			t.Fatal(err)
+		}
+		_, err := calibrationFromConfig(section)
+		if err == nil || !strings.Contains(err.Error(), e.reason) {
+			t.Errorf("%q: expected %q, got %v", e.section, e.reason, err)
+		}
+	}
+}
+
+func TestCalibratedPrediction(t *testing.T) {
+	h := testHolder(t, "default", "0:bias:-1\n1:geo=us:1.5\n")
+	h.calibration = platt{a: 2, b: 0.5}
+	inf := testInferencer(h)
+	req := &pb.Request{Geo: "us"}
+
+	resp, err := inf.PredictProba(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := Sigmoid(2*0.5 + 0.5); math.Abs(resp.Proba-expected) > 1e-12 {
+		t.Errorf("proba %v != %v", resp.Proba, expected)
+	}
+
+	explained, err := inf.Explain(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if explained.Proba != resp.Proba || explained.RawProba != Sigmoid(0.5) {
+		t.Errorf("explain proba %v, raw %v", explained.Proba, explained.RawProba)
+	}
+
+	info, err := inf.GetModelInfo(context.Background(), &pb.ModelInfoRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := info.Calibration; c.Type != "platt" || c.A != 2 || c.B != 0.5 {
+		t.Errorf("unexpected calibration info %v", c)
+	}
+}
+
+func TestCalibrationRequiresLogisticLink(t *testing.T) {
+	probit := writeTable(t, "#link=probit\n0:bias:-1\n1:geo=us:1.5\n")
+	defer os.Remove(probit)
+
+	data := `
+models:
+ - name: default
+   path: ` + probit + `
+   calibration:
+     type: platt
+     a: 1
+     b: 0
+`
+	config := make(Yaml)
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRegistry(config); err == nil || !strings.Contains(err.Error(), "applies to logistic models only") {
+		t.Errorf("expected calibrated probit model to be rejected, got %v", err)
+	}
+
+	// model file which changes link isn't reloaded
+	h := testHolder(t, "default", "0:bias:-1\n1:geo=us:1.5\n")
+	h.calibration = downsampling{rate: 0.5}
+	original := h.Current()
+	h.path = probit
+	if err := h.Reload(); err == nil || !strings.Contains(err.Error(), "applies to logistic models only") {
+		t.Errorf("expected reload of probit model to fail, got %v", err)
+	}
+	if h.Current() != original {
+		t.Errorf("model is replaced by the one which can't be calibrated")
+	}
+}
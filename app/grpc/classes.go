@@ -0,0 +1,229 @@
+package serving
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/go-code/goinfer/api"
+	"github.com/go-code/goinfer/app/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// linkSoftmax is link of multi-class models, it isn't in linkFuncs
+// because it transforms scores of all classes at once
+const linkSoftmax = "softmax"
+
+// Classes keeps coefficients of multinomial logistic regression.
+// Header of model file declares labels of classes
+//
+//	#classes=banner,video,native
+//	0:bias:0.1,-0.2,0.3
+//	1:geo=us:0.5,0.25,-1
+//
+// and each line has one coefficient per class in the same order.
+// All classes share variables and vocabularies of model, so value
+// of variable is resolved once per request for all classes.
+//
+// Coefficients of the first class are also kept in plain fields
+// of Model, so introspection and validation work as for binary model
+type Classes struct {
+	labels  []string
+	bias    []float64
+	coef    []CoeffStore
+	other   []OtherStore
+	weights []map[FeatureName]float64
+}
+
+// classesFromHeader reads labels of classes, it returns nil
+// if model isn't multi-class
+func classesFromHeader(header *modelHeader) (*Classes, *ModelError) {
+	list, ok := header.values["classes"]
+	if !ok {
+		return nil, nil
+	}
+	if _, ok := header.values["hash"]; ok {
+		return nil, header.fail("classes", "hashed model can't have classes")
+	}
+	if _, ok := header.values["link"]; ok {
+		return nil, header.fail("classes", "multi-class model has softmax link")
+	}
+
+	labels := strings.Split(list, ",")
+	if len(labels) < 2 {
+		return nil, header.fail("classes", "expected at least 2 classes, got %q", list)
+	}
+	seen := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		if label == "" || seen[label] {
+			return nil, header.fail("classes", "labels of classes must be unique and non-empty, got %q", list)
+		}
+		seen[label] = true
+	}
+
+	c := &Classes{
+		labels:  labels,
+		bias:    make([]float64, len(labels)),
+		coef:    make([]CoeffStore, len(labels)),
+		other:   make([]OtherStore, len(labels)),
+		weights: make([]map[FeatureName]float64, len(labels)),
+	}
+	for i := range labels {
+		c.coef[i] = make(CoeffStore)
+		c.other[i] = make(OtherStore)
+		c.weights[i] = make(map[FeatureName]float64)
+	}
+	return c, nil
+}
+
+// parseCoefs reads k comma separated coefficients of line
+func parseCoefs(s string, k int) ([]float64, error) {
+	items := []string{s}
+	if k > 1 {
+		items = strings.Split(s, ",")
+	}
+	if len(items) != k {
+		return nil, fmt.Errorf("expected %d coefficients, got %q", k, s)
+	}
+	coefs := make([]float64, k)
+	for i, item := range items {
+		c, err := strconv.ParseFloat(item, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse coefficient %q", item)
+		}
+		if math.IsNaN(c) || math.IsInf(c, 0) {
+			return nil, fmt.Errorf("coefficient is %v", c)
+		}
+		coefs[i] = c
+	}
+	return coefs, nil
+}
+
+func (c *Classes) setCoef(v Variable, value Value, coefs []float64) {
+	for i, coef := range coefs {
+		if c.coef[i][v] == nil {
+			c.coef[i][v] = make(ValueStore)
+		}
+		c.coef[i][v][value] = coef
+	}
+}
+
+func (c *Classes) setOther(v Variable, coefs []float64) {
+	for i, coef := range coefs {
+		c.other[i][v] = coef
+	}
+}
+
+func (c *Classes) setWeight(f FeatureName, coefs []float64) {
+	for i, coef := range coefs {
+		c.weights[i][f] = coef
+	}
+}
+
+// classScores computes score of every class
+func (m *Model) classScores(req *pb.Request) []float64 {
+	c := m.classes
+	scores := make([]float64, len(c.labels))
+	copy(scores, c.bias)
+
+	for v := range m.variables {
+		value, err := v.makeValue(req, m.schema, &m.values)
+		seen := false
+		for i := range scores {
+			coef, ok := 0.0, false
+			if err == nil {
+				coef, ok = c.coef[i][v][value]
+			}
+			if !ok {
+				coef = c.other[i][v]
+			}
+			seen = seen || ok
+			scores[i] += coef
+		}
+		if !seen {
+			metrics.UnseenValue(m.name, m.labels[v])
+		}
+	}
+
+	for i := range scores {
+		for f, w := range c.weights[i] {
+			if x, ok := m.schema.number(f, req); ok {
+				scores[i] += w * x
+			}
+		}
+	}
+	return scores
+}
+
+// softmax transforms scores of classes to probabilities
+func softmax(scores []float64) []float64 {
+	max := math.Inf(-1)
+	for _, s := range scores {
+		max = math.Max(max, s)
+	}
+	probas := make([]float64, len(scores))
+	var sum float64
+	for i, s := range scores {
+		probas[i] = math.Exp(s - max)
+		sum += probas[i]
+	}
+	for i := range probas {
+		probas[i] /= sum
+	}
+	return probas
+}
+
+// PredictClasses predicts probability of every class
+// of multi-class model, classes go in the order of model file
+func (inf *Inferencer) PredictClasses(c context.Context,
+	req *pb.Request) (*pb.ClassesResponse, error) {
+
+	now := time.Now()
+	var model, variant string
+	defer func() {
+		metrics.ProbabilityLatency("predict_classes", model, variant,
+			time.Since(now).Seconds())
+	}()
+
+	h, variant, err := inf.registry.Route(req)
+	if err != nil {
+		return nil, err
+	}
+	model = h.name
+
+	m := h.Current()
+	if m.classes == nil {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"model %s has no classes, it's served by PredictProba", model)
+	}
+
+	scores := m.classScores(req)
+	probas := softmax(scores)
+	resp := &pb.ClassesResponse{
+		ModelName: model,
+		Variant:   variant,
+		Classes:   make([]*pb.ClassProba, len(scores)),
+	}
+	for i, label := range m.classes.labels {
+		resp.Classes[i] = &pb.ClassProba{
+			Label: label,
+			Score: scores[i],
+			Proba: probas[i],
+		}
+	}
+	return resp, nil
+}
+
+// singleClass rejects multi-class model in handlers
+// which predict single outcome
+func singleClass(model string, m *Model) error {
+	if m.classes != nil {
+		return status.Errorf(codes.FailedPrecondition,
+			"model %s has classes, it's served by PredictClasses", model)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package serving
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const classesModel = `#classes=banner,video,native
+0:bias:0.5,-0.5,0
+1:geo=us:1,0.25,-1
+2:geo=fit.other:-1,0,1
+3:geoXXbrowser=usX~X8:0.5,0.5,0.5
+4:bid_floor:2,0,-2`
+
+func TestPredictClasses(t *testing.T) {
+	inf := testInferencer(testHolder(t, "default", classesModel))
+
+	cases := []struct {
+		req    *pb.Request
+		scores []float64
+	}{
+		{
+			&pb.Request{Geo: "us", Browser: 8, Features: map[string]string{"bid_floor": "0.5"}},
+			[]float64{0.5 + 1 + 0.5 + 1, -0.5 + 0.25 + 0.5, 0 - 1 + 0.5 - 1},
+		},
+		// unseen geo falls back to fit.other, interaction has no fit.other
+		{
+			&pb.Request{Geo: "de", Browser: 8},
+			[]float64{0.5 - 1, -0.5, 1},
+		},
+	}
+
+	for _, c := range cases {
+		resp, err := inf.PredictClasses(context.Background(), c.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Classes) != 3 {
+			t.Fatalf("unexpected response %v", resp)
+		}
+
+		var sum float64
+		for _, s := range c.scores {
+			sum += math.Exp(s)
+		}
+		for i, label := range []string{"banner", "video", "native"} {
+			class := resp.Classes[i]
+			expected := math.Exp(c.scores[i]) / sum
+			if class.Label != label || math.Abs(class.Score-c.scores[i]) > 1e-12 ||
+				math.Abs(class.Proba-expected) > 1e-12 {
+				t.Errorf("%v: class %v, expected %s %v %v", c.req, class, label, c.scores[i], expected)
+			}
+		}
+	}
+
+	// single outcome handlers reject multi-class model and vice versa
+	req := &pb.Request{Geo: "us"}
+	if _, err := inf.PredictProba(context.Background(), req); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("PredictProba: expected FailedPrecondition, got %v", err)
+	}
+	if _, err := inf.Predict(context.Background(), req); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Predict: expected FailedPrecondition, got %v", err)
+	}
+	if _, err := inf.Explain(context.Background(), req); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Explain: expected FailedPrecondition, got %v", err)
+	}
+	single := testInferencer(testHolder(t, "default", "0:geo=us:1"))
+	if _, err := single.PredictClasses(context.Background(), req); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("PredictClasses: expected FailedPrecondition, got %v", err)
+	}
+
+	info, err := inf.GetModelInfo(context.Background(), &pb.ModelInfoRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Link != linkSoftmax || strings.Join(info.Classes, ",") != "banner,video,native" {
+		t.Errorf("unexpected model info %v %v", info.Link, info.Classes)
+	}
+}
+
+func TestSoftmax(t *testing.T) {
+	// large scores don't overflow
+	probas := softmax([]float64{1000, 1000})
+	if probas[0] != 0.5 || probas[1] != 0.5 {
+		t.Errorf("softmax %v", probas)
+	}
+}
+
+func TestClassesErrors(t *testing.T) {
+	cases := []struct {
+		lines  []string
+		line   int
+		reason string
+	}{
+		{[]string{"#classes=a", "0:geo=us:1"}, 1, `expected at least 2 classes, got "a"`},
+		{[]string{"#classes=a,a", "0:geo=us:1"}, 1, `labels of classes must be unique and non-empty, got "a,a"`},
+		{[]string{"#classes=a,b", "#link=identity", "0:geo=us:1"}, 1, "multi-class model has softmax link"},
+		{[]string{"#classes=a,b", "0:geo=us:1"}, 2, `expected 2 coefficients, got "1"`},
+		{[]string{"#classes=a,b", "0:geo=us:1,x"}, 2, `failed to parse coefficient "x"`},
+		{[]string{"#classes=a,b", "0:geo=us:1,2:5"}, 2, "multi-class model has no counts"},
+	}
+
+	for _, c := range cases {
+		_, err := parse(&c.lines, DefaultSchema())
+		errs, ok := err.(ModelErrors)
+		if !ok || len(errs) != 1 || errs[0].Line != c.line || errs[0].Reason != c.reason {
+			t.Errorf("%v: expected %d: %s, got %v", c.lines, c.line, c.reason, err)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package serving
+
+import (
+	"math"
+	"math/bits"
+
+	pb "github.com/go-code/goinfer/api"
+	"github.com/go-code/goinfer/app/metrics"
+)
+
+// maxValueLen is the size of buffer for request values,
+// longer values are still supported but allocate
+const maxValueLen = 128
+
+// compiled is dense representation of model used for scoring.
+//
+// Coefficients of all variables are kept in one slice,
+// each variable owns the range starting from its offset.
+// Index of value inside the range is mixed radix number
+// made of value tokens with vocabulary sizes as bases, so
+// lookup is a few multiplications instead of map access.
+//
+// Variables whose range would be much larger than the number
+// of their coefficients are sparse, their indices are looked
+// up in map. Missing coefficients are NaN.
+//
+// variances are kept along with coefficients if model file
+// has counts, see Model.confidence
+//
+// Indices of wide variables don't fit uint64, they are
+// looked up by Value and allocate, it never happens
+// for vocabularies of real models
+type compiled struct {
+	coefs     []float64
+	variances []float64
+	vars      []compiledVar
+	// index is vocabulary of each feature
+	index []ValueIndex
+}
+
+type compiledVar struct {
+	variable Variable
+	wide     bool
+	features []FeatureName
+	strides  []uint64
+	offset   uint64
+	// sparse maps index to position in coefs
+	sparse map[uint64]uint64
+	other  float64
+	// otherVariance is variance of fallback to "fit.other"
+	otherVariance float64
+	label         string
+}
+
+// denseRatio limits size of dense range relative to
+// the number of coefficients of variable
+const (
+	denseRatio = 8
+	denseMin   = 1024
+)
+
+func compile(m *Model) *compiled {
+	c := &compiled{index: make([]ValueIndex, m.schema.Len())}
+	for f := range c.index {
+		c.index[f] = m.values.store[FeatureName(f)]
+	}
+
+	for variable := range m.variables {
+		v := compiledVar{
+			variable: variable,
+			features: make([]FeatureName, variable.size),
+			strides:  make([]uint64, variable.size),
+			other:    m.other[variable],
+			label:    m.labels[variable],
+		}
+		v.otherVariance = m.otherVariance(variable)
+
+		size, overflow := uint64(1), false
+		for i := range v.features {
+			f := variable.feature(i)
+			v.features[i] = f
+			v.strides[i] = size
+
+			hi, lo := bits.Mul64(size, uint64(m.values.uniqs[f]))
+			overflow = overflow || hi != 0
+			size = lo
+		}
+
+		coef := m.coef[variable]
+		if overflow {
+			v.wide = true
+			c.vars = append(c.vars, v)
+			continue
+		}
+		if size > uint64(denseRatio*len(coef)+denseMin) {
+			v.sparse = make(map[uint64]uint64, len(coef))
+		} else {
+			v.offset = uint64(len(c.coefs))
+			for i := uint64(0); i < size; i++ {
+				c.coefs = append(c.coefs, math.NaN())
+			}
+		}
+
+		for value, coef := range coef {
+			pos := v.offset + v.index(value)
+			if v.sparse != nil {
+				pos = uint64(len(c.coefs))
+				v.sparse[v.index(value)] = pos
+				c.coefs = append(c.coefs, coef)
+			} else {
+				c.coefs[pos] = coef
+			}
+		}
+		c.vars = append(c.vars, v)
+	}
+
+	if m.hasCounts() {
+		c.variances = make([]float64, len(c.coefs))
+		for i := range c.vars {
+			v := &c.vars[i]
+			if v.wide {
+				continue
+			}
+			for value := range m.coef[v.variable] {
+				pos := v.offset + v.index(value)
+				if v.sparse != nil {
+					pos = v.sparse[v.index(value)]
+				}
+				c.variances[pos] = m.variance(v.variable, value)
+			}
+		}
+	}
+	return c
+}
+
+// index computes position of value in the range of variable
+func (v *compiledVar) index(value Value) uint64 {
+	var idx uint64
+	for i := range v.strides {
+		idx += v.strides[i] * uint64(value.token(i))
+	}
+	return idx
+}
+
+// scoreStats describe how reliable score is, see Model.confidence
+type scoreStats struct {
+	// found is the number of variables whose values
+	// are seen in training out of total
+	found, total int
+	// variance is the sum of variances of coefficients
+	variance float64
+}
+
+// score sums coefficients of categorical variables,
+// it doesn't allocate unless values are longer than
+// maxValueLen or variable is wide
+func (c *compiled) score(req *pb.Request, m *Model, stats *scoreStats) float64 {
+	var score float64
+	var buf [maxValueLen]byte
+	stats.total += len(c.vars)
+	for i := range c.vars {
+		v := &c.vars[i]
+
+		if v.wide {
+			if value, err := v.variable.makeValue(req, m.schema, &m.values); err == nil {
+				if coef, ok := m.coef[v.variable][value]; ok {
+					score += coef
+					stats.found++
+					stats.variance += m.variance(v.variable, value)
+					continue
+				}
+			}
+			metrics.UnseenValue(m.name, v.label)
+			score += v.other
+			stats.variance += v.otherVariance
+			continue
+		}
+
+		idx, found := uint64(0), true
+		for j, f := range v.features {
+			b, ok := m.schema.appendValue(buf[:0], f, req)
+			if !ok {
+				found = false
+				break
+			}
+			token, ok := c.index[f][string(b)]
+			if !ok {
+				found = false
+				break
+			}
+			idx += v.strides[j] * uint64(token)
+		}
+
+		if found {
+			pos := v.offset + idx
+			if v.sparse != nil {
+				pos, found = v.sparse[idx]
+			} else {
+				found = !math.IsNaN(c.coefs[pos])
+			}
+			if found {
+				score += c.coefs[pos]
+				stats.found++
+				if c.variances != nil {
+					stats.variance += c.variances[pos]
+				}
+				continue
+			}
+		}
+
+		metrics.UnseenValue(m.name, v.label)
+		score += v.other
+		stats.variance += v.otherVariance
+	}
+	return score
+}
@@ -0,0 +1,189 @@
+package serving
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+)
+
+// benchModel has dense and sparse variables, numeric
+// features and values which are unseen in training
+func benchModel(t testing.TB) *Model {
+	lines := []string{
+		"0:bias:-1",
+		"1:geo=fit.other:-0.5",
+		"2:buckets=hour:6,12,18",
+		"3:bid_floor:0.125",
+	}
+	geos := []string{"us", "gb", "de", "fr", "it"}
+	for i, geo := range geos {
+		lines = append(lines, fmt.Sprintf("%d:geo=%s:%v", len(lines), geo, float64(i)/8))
+	}
+	for i := 0; i < 5000; i++ {
+		lines = append(lines,
+			fmt.Sprintf("%d:banner_id=%d:%v", len(lines), i, float64(i%7)/16),
+			fmt.Sprintf("%d:zone_idXXbanner_id=%dX~X%d:%v", len(lines), i%100, i, float64(i%5)/32))
+	}
+	for h := 0; h < 4; h++ {
+		lines = append(lines, fmt.Sprintf("%d:hourXXgeo=%dX~Xus:%v", len(lines), h, float64(h)/4))
+	}
+
+	m, err := parse(&lines, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.name = "bench"
+	return m
+}
+
+// mapScore is reference scoring by model maps
+func mapScore(m *Model, req *pb.Request) float64 {
+	score := m.bias
+	for variable := range m.variables {
+		value, err := variable.makeValue(req, m.schema, &m.values)
+		if coef, ok := m.coef[variable][value]; err == nil && ok {
+			score += coef
+			continue
+		}
+		score += m.other[variable]
+	}
+	for f, w := range m.weights {
+		if x, err := m.schema.numeric(f, req); err == nil {
+			score += w * x
+		}
+	}
+	return score
+}
+
+func benchRequest() *pb.Request {
+	return &pb.Request{
+		BannerId: 1234,
+		ZoneId:   34,
+		Geo:      "us",
+		Features: map[string]string{"hour": "7", "bid_floor": "0.5"},
+	}
+}
+
+func TestCompiledScore(t *testing.T) {
+	m := benchModel(t)
+
+	var sparse, dense int
+	for _, v := range m.compiled.vars {
+		if v.sparse != nil {
+			sparse++
+		} else {
+			dense++
+		}
+	}
+	if sparse == 0 || dense == 0 {
+		t.Fatalf("expected both sparse and dense variables, got %d and %d", sparse, dense)
+	}
+
+	for i := 0; i < 200; i++ {
+		req := &pb.Request{
+			BannerId: uint64(i * 31 % 5100),
+			ZoneId:   uint64(i % 120),
+			Geo:      []string{"us", "gb", "ru"}[i%3],
+			Features: map[string]string{
+				"hour":      []string{"1", "7", "13", "20", "x", ""}[i%6],
+				"bid_floor": []string{"0.5", "", "3"}[i%3],
+			},
+		}
+		score, err := m.score(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := mapScore(m, req); score != expected {
+			t.Errorf("%v: score %v != %v", req, score, expected)
+		}
+	}
+}
+
+func TestScoreAllocs(t *testing.T) {
+	m := benchModel(t)
+	hashed := testHolder(t, "hashed", strings.Join([]string{
+		"#hash=fnv1a32",
+		"#hash_buckets=4096",
+		"#variables=geo,zone_idXXbanner_id",
+		"0:h=1:1",
+	}, "\n")).Current()
+
+	cases := map[string]struct {
+		m   *Model
+		req *pb.Request
+	}{
+		"seen":   {m, benchRequest()},
+		"unseen": {m, &pb.Request{BannerId: 9999999, Geo: "ru"}},
+		"hashed": {hashed, benchRequest()},
+	}
+	for name, c := range cases {
+		allocs := testing.AllocsPerRun(100, func() {
+			c.m.score(c.req)
+		})
+		if allocs != 0 {
+			t.Errorf("%s: score allocates %v times", name, allocs)
+		}
+	}
+	h := &ModelHolder{name: "bench"}
+	h.model.Store(m)
+	inf := testInferencer(h)
+	req := benchRequest()
+	allocs := testing.AllocsPerRun(100, func() {
+		inf.predict(req)
+	})
+	// only response is allocated
+	if allocs != 1 {
+		t.Errorf("predict allocates %v times", allocs)
+	}
+}
+
+func TestHasher(t *testing.T) {
+	for _, key := range []string{"", "geo=us", "zone_idXXbanner_id=1X~X2"} {
+		h64, h32 := hashFuncs["fnv1a64"], hashFuncs["fnv1a32"]
+		h64.writeString(key)
+		h32.write([]byte(key))
+
+		f64, f32 := fnv.New64a(), fnv.New32a()
+		f64.Write([]byte(key))
+		f32.Write([]byte(key))
+
+		if h64.sum != f64.Sum64() || h32.sum != uint64(f32.Sum32()) {
+			t.Errorf("%q: hash differs from hash/fnv", key)
+		}
+	}
+}
+
+func BenchmarkScore(b *testing.B) {
+	m := benchModel(b)
+	req := benchRequest()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.score(req)
+	}
+}
+
+func BenchmarkMapScore(b *testing.B) {
+	m := benchModel(b)
+	req := benchRequest()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mapScore(m, req)
+	}
+}
+
+func BenchmarkPredictProba(b *testing.B) {
+	h := &ModelHolder{name: "bench"}
+	h.model.Store(benchModel(b))
+	inf := testInferencer(h)
+	req := benchRequest()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		inf.predict(req)
+	}
+}
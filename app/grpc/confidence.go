@@ -0,0 +1,68 @@
+package serving
+
+import (
+	"fmt"
+	"math"
+)
+
+// Confidence tells how much the prediction can be trusted,
+// it's in range [0, 1]. Definition is selected in config
+//
+//	confidence: <coverage (default), variance or constant>
+//
+// coverage is the fraction of model variables whose request
+// values are seen in training, the rest fall back to "fit.other".
+//
+// variance uses goFTRL "n" accumulators written after coefficients
+// in model file. Variance of coefficient is approximately 1/(1+n),
+// unseen values without "fit.other" have prior variance 1 and
+// coefficients without counts are considered exact. Variance of
+// logit is the sum of them, it's mapped to confidence by
+// 1/sqrt(1+pi*variance/8), the factor which shrinks logit of
+// bayesian logistic regression.
+//
+// constant is always 1
+const (
+	confidenceCoverage = "coverage"
+	confidenceVariance = "variance"
+	confidenceConstant = "constant"
+
+	defaultConfidence = confidenceCoverage
+)
+
+// priorVariance is variance of coefficient
+// which hasn't been trained
+const priorVariance = 1.0
+
+func countVariance(n float64) float64 {
+	return 1 / (1 + n)
+}
+
+// confidenceFromConfig reads definition of confidence
+func confidenceFromConfig(config Yaml) (string, error) {
+	v, ok := config["confidence"]
+	if !ok {
+		return defaultConfidence, nil
+	}
+	switch mode, _ := v.(string); mode {
+	case confidenceCoverage, confidenceVariance, confidenceConstant:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown confidence %v", v)
+	}
+}
+
+// confidence computes confidence of score by its statistics
+func confidence(mode string, stats scoreStats) float64 {
+	switch mode {
+	case confidenceConstant:
+		return 1
+	case confidenceVariance:
+		return 1 / math.Sqrt(1+math.Pi*stats.variance/8)
+	default:
+		if stats.total == 0 {
+			return 1
+		}
+		return float64(stats.found) / float64(stats.total)
+	}
+}
@@ -0,0 +1,112 @@
+package serving
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+)
+
+const countedModel = `0:bias:-1:3
+1:geo=us:1:99
+2:geo=fit.other:-1:1
+3:browser=8:0.5
+4:geoXXbrowser=usX~X8:0.25:9
+5:bid_floor:0.5:7`
+
+func TestConfidence(t *testing.T) {
+	m := testHolder(t, "default", countedModel).Current()
+
+	cases := []struct {
+		req      *pb.Request
+		coverage float64
+		variance float64
+	}{
+		{
+			&pb.Request{Geo: "us", Browser: 8, Features: map[string]string{"bid_floor": "1"}},
+			1,
+			1.0/4 + 1.0/100 + 0 + 1.0/10,
+		},
+		// unseen geo falls back to fit.other, interaction has no fit.other
+		{
+			&pb.Request{Geo: "de", Browser: 8},
+			1.0 / 4,
+			1.0/4 + 1.0/2 + 0 + priorVariance,
+		},
+	}
+
+	for _, c := range cases {
+		_, stats, err := m.scoreStats(c.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conf := confidence(confidenceCoverage, stats); conf != c.coverage {
+			t.Errorf("%v: coverage %v != %v", c.req, conf, c.coverage)
+		}
+		if math.Abs(stats.variance-c.variance) > 1e-12 {
+			t.Errorf("%v: variance %v != %v", c.req, stats.variance, c.variance)
+		}
+		expected := 1 / math.Sqrt(1+math.Pi*c.variance/8)
+		if conf := confidence(confidenceVariance, stats); math.Abs(conf-expected) > 1e-12 {
+			t.Errorf("%v: confidence %v != %v", c.req, conf, expected)
+		}
+		if conf := confidence(confidenceConstant, stats); conf != 1 {
+			t.Errorf("%v: constant confidence %v", c.req, conf)
+		}
+	}
+
+	// counts survive conversion to binary format
+	var buf bytes.Buffer
+	if err := WriteBinary(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := parseBinary(buf.Bytes(), DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		_, s1, _ := m.scoreStats(c.req)
+		_, s2, _ := decoded.scoreStats(c.req)
+		if s1 != s2 {
+			t.Errorf("%v: binary stats %v != %v", c.req, s2, s1)
+		}
+	}
+}
+
+func TestConfidenceInResponse(t *testing.T) {
+	inf := testInferencer(testHolder(t, "default", "0:geo=us:1\n1:browser=8:1\n"))
+
+	resp, err := inf.PredictProba(context.Background(), &pb.Request{Geo: "us", Browser: 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Confidence != 0.5 {
+		t.Errorf("confidence %v != 0.5", resp.Confidence)
+	}
+}
+
+func TestConfidenceFromConfig(t *testing.T) {
+	for _, c := range []struct {
+		config Yaml
+		mode   string
+		err    string
+	}{
+		{Yaml{}, confidenceCoverage, ""},
+		{Yaml{"confidence": "variance"}, confidenceVariance, ""},
+		{Yaml{"confidence": "magic"}, "", "unknown confidence"},
+	} {
+		mode, err := confidenceFromConfig(c.config)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("%v: expected %q, got %v", c.config, c.err, err)
+			}
+			continue
+		}
+		if err != nil || mode != c.mode {
+			t.Errorf("%v: %q, %v", c.config, mode, err)
+		}
+	}
+}
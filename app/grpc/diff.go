@@ -0,0 +1,360 @@
+package serving
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	pb "github.com/go-code/goinfer/api"
+	"github.com/golang/protobuf/jsonpb"
+)
+
+// ModelDiff describes how new model differs from old one.
+// Models are compared by names of variables and values,
+// so they can extend base schema differently
+type ModelDiff struct {
+	Added, Removed []string
+	Vocabulary     []VocabularyDiff
+	// Changed is the number of coefficients which are
+	// added, removed or have different value
+	Changed int
+	// Top are changed coefficients with the largest deltas
+	Top []CoefficientDiff
+	// Scores are changes of scores on sample requests,
+	// nil if there is no sample
+	Scores *ScoreChanges
+}
+
+// VocabularyDiff is growth of vocabulary of feature
+type VocabularyDiff struct {
+	Feature        string
+	Old, New       int
+	Added, Removed int
+}
+
+// CoefficientDiff is change of coefficient, Key is formatted as
+// name in model file, e.g. "geo=us". Missing coefficient is zero
+type CoefficientDiff struct {
+	Key            string
+	Old, New       float64
+	Added, Removed bool
+}
+
+// Delta is the change of coefficient
+func (c CoefficientDiff) Delta() float64 {
+	return c.New - c.Old
+}
+
+// ScoreChanges is distribution of score changes on sample requests,
+// quantiles are of absolute changes
+type ScoreChanges struct {
+	Requests int
+	// Failed are requests which either model can't score
+	Failed        int
+	Mean, MeanAbs float64
+	P50, P90, P99 float64
+	Max, MaxProba float64
+}
+
+// DiffModels compares models, top limits the number
+// of coefficient deltas which are reported
+func DiffModels(before, after *Model, top int) *ModelDiff {
+	d := &ModelDiff{}
+
+	oldVars, newVars := variableNames(before), variableNames(after)
+	for name := range newVars {
+		if !oldVars[name] {
+			d.Added = append(d.Added, name)
+		}
+	}
+	for name := range oldVars {
+		if !newVars[name] {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+
+	oldVocab, newVocab := vocabularies(before), vocabularies(after)
+	features := make(map[string]bool)
+	for f := range oldVocab {
+		features[f] = true
+	}
+	for f := range newVocab {
+		features[f] = true
+	}
+	for f := range features {
+		v := VocabularyDiff{Feature: f, Old: len(oldVocab[f]), New: len(newVocab[f])}
+		for val := range newVocab[f] {
+			if !oldVocab[f][val] {
+				v.Added++
+			}
+		}
+		for val := range oldVocab[f] {
+			if !newVocab[f][val] {
+				v.Removed++
+			}
+		}
+		if v.Old > 0 || v.New > 0 {
+			d.Vocabulary = append(d.Vocabulary, v)
+		}
+	}
+	sort.Slice(d.Vocabulary, func(i, j int) bool {
+		return d.Vocabulary[i].Feature < d.Vocabulary[j].Feature
+	})
+
+	oldCoefs, newCoefs := coefficients(before), coefficients(after)
+	var changes []CoefficientDiff
+	for key, n := range newCoefs {
+		o, ok := oldCoefs[key]
+		if !ok || o != n {
+			changes = append(changes, CoefficientDiff{Key: key, Old: o, New: n, Added: !ok})
+		}
+	}
+	for key, o := range oldCoefs {
+		if _, ok := newCoefs[key]; !ok {
+			changes = append(changes, CoefficientDiff{Key: key, Old: o, Removed: true})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		di, dj := math.Abs(changes[i].Delta()), math.Abs(changes[j].Delta())
+		if di != dj {
+			return di > dj
+		}
+		return changes[i].Key < changes[j].Key
+	})
+	d.Changed = len(changes)
+	if len(changes) > top {
+		changes = changes[:top]
+	}
+	d.Top = changes
+	return d
+}
+
+// variableNames lists variables of model as in model file
+func variableNames(m *Model) map[string]bool {
+	names := make(map[string]bool)
+	for v := range m.variables {
+		names[m.labels[v]] = true
+	}
+	if m.hashing != nil {
+		for _, v := range m.hashing.variables {
+			names[v.fileName(m.schema)] = true
+		}
+	}
+	return names
+}
+
+// vocabularies lists values of features by feature name
+func vocabularies(m *Model) map[string]map[string]bool {
+	vocab := make(map[string]map[string]bool)
+	for f, values := range m.values.store {
+		set := make(map[string]bool, len(values))
+		for val := range values {
+			set[val] = true
+		}
+		vocab[string(m.schema.Name(f))] = set
+	}
+	return vocab
+}
+
+// coefficients lists all coefficients of model by names
+// they have in model file. Coefficients of multi-class
+// model are suffixed by label of class, e.g. "geo=us[video]"
+func coefficients(m *Model) map[string]float64 {
+	coefs := make(map[string]float64)
+	set := func(key string, c float64, class func(i int) float64) {
+		if m.classes == nil {
+			coefs[key] = c
+			return
+		}
+		for i, label := range m.classes.labels {
+			coefs[key+"["+label+"]"] = class(i)
+		}
+	}
+
+	set(BiasName, m.bias, func(i int) float64 { return m.classes.bias[i] })
+	for f, w := range m.weights {
+		f := f
+		set(string(m.schema.Name(f)), w, func(i int) float64 { return m.classes.weights[i][f] })
+	}
+
+	vocab := m.vocabulary()
+	for v := range m.variables {
+		v := v
+		for value, c := range m.coef[v] {
+			value := value
+			vals := make([]string, v.size)
+			for i := range vals {
+				vals[i] = vocab[v.feature(i)][value.token(i)]
+			}
+			key := m.labels[v] + "=" + strings.Join(vals, FeatureValueSeparator)
+			set(key, c, func(i int) float64 { return m.classes.coef[i][v][value] })
+		}
+		if c, ok := m.other[v]; ok {
+			set(m.labels[v]+"=fit.other", c, func(i int) float64 { return m.classes.other[i][v] })
+		}
+	}
+
+	if h := m.hashing; h != nil {
+		for i, w := range h.weights {
+			if w != 0 {
+				coefs["h="+strconv.Itoa(i)] = w
+			}
+		}
+	}
+	return coefs
+}
+
+// CompareScores computes distribution of score changes on requests.
+// Scores of multi-class models are compared class by class
+func CompareScores(before, after *Model, reqs []*pb.Request) *ScoreChanges {
+	s := &ScoreChanges{}
+	var deltas []float64
+	for _, req := range reqs {
+		s.Requests++
+		d, dp, err := scoreChange(before, after, req)
+		if err != nil {
+			s.Failed++
+			continue
+		}
+		s.Mean += d
+		deltas = append(deltas, math.Abs(d))
+		s.MaxProba = math.Max(s.MaxProba, dp)
+	}
+	if len(deltas) == 0 {
+		return s
+	}
+
+	sort.Float64s(deltas)
+	for _, d := range deltas {
+		s.MeanAbs += d
+	}
+	s.Mean /= float64(len(deltas))
+	s.MeanAbs /= float64(len(deltas))
+	quantile := func(q float64) float64 {
+		return deltas[int(q*float64(len(deltas)-1))]
+	}
+	s.P50, s.P90, s.P99 = quantile(0.5), quantile(0.9), quantile(0.99)
+	s.Max = deltas[len(deltas)-1]
+	return s
+}
+
+// scoreChange returns change of score of request and absolute change
+// of its prediction. Change of multi-class model is the largest one
+// of its classes
+func scoreChange(before, after *Model, req *pb.Request) (float64, float64, error) {
+	if (before.classes == nil) != (after.classes == nil) {
+		return 0, 0, fmt.Errorf("only one of models is multi-class")
+	}
+
+	if before.classes != nil {
+		if strings.Join(before.classes.labels, ",") != strings.Join(after.classes.labels, ",") {
+			return 0, 0, fmt.Errorf("models have different classes")
+		}
+		s1, s2 := before.classScores(req), after.classScores(req)
+		p1, p2 := softmax(s1), softmax(s2)
+		var d, dp float64
+		for i := range s1 {
+			if math.Abs(s2[i]-s1[i]) >= math.Abs(d) {
+				d = s2[i] - s1[i]
+			}
+			dp = math.Max(dp, math.Abs(p2[i]-p1[i]))
+		}
+		return d, dp, nil
+	}
+
+	s1, err := before.score(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	s2, err := after.score(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	p1, p2 := linkFuncs[before.link](s1), linkFuncs[after.link](s2)
+	return s2 - s1, math.Abs(p2 - p1), nil
+}
+
+// LoadRequests reads JSONL file of requests. Line is either
+// request or shadow log record (see Shadow) with request in it
+func LoadRequests(path string) ([]*pb.Request, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reqs []*pb.Request
+	unmarshaler := jsonpb.Unmarshaler{AllowUnknownFields: true}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var record struct {
+			Request json.RawMessage `json:"request"`
+		}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		if len(record.Request) > 0 {
+			line = record.Request
+		}
+
+		req := &pb.Request{}
+		if err := unmarshaler.Unmarshal(bytes.NewReader(line), req); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, scanner.Err()
+}
+
+// Write prints human readable report of diff
+func (d *ModelDiff) Write(w io.Writer) error {
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintf(b, "variables: %d added, %d removed\n", len(d.Added), len(d.Removed))
+	for _, name := range d.Added {
+		fmt.Fprintf(b, "  + %s\n", name)
+	}
+	for _, name := range d.Removed {
+		fmt.Fprintf(b, "  - %s\n", name)
+	}
+
+	fmt.Fprintln(b, "vocabulary:")
+	for _, v := range d.Vocabulary {
+		fmt.Fprintf(b, "  %s: %d -> %d (+%d -%d)\n", v.Feature, v.Old, v.New, v.Added, v.Removed)
+	}
+
+	fmt.Fprintf(b, "coefficients: %d changed, top %d by delta:\n", d.Changed, len(d.Top))
+	for _, c := range d.Top {
+		switch {
+		case c.Added:
+			fmt.Fprintf(b, "  + %s: %v\n", c.Key, c.New)
+		case c.Removed:
+			fmt.Fprintf(b, "  - %s: %v\n", c.Key, c.Old)
+		default:
+			fmt.Fprintf(b, "    %s: %v -> %v (%+g)\n", c.Key, c.Old, c.New, c.Delta())
+		}
+	}
+
+	if s := d.Scores; s != nil {
+		fmt.Fprintf(b, "scores: %d requests, %d failed\n", s.Requests, s.Failed)
+		fmt.Fprintf(b, "  delta mean %+g, mean abs %g\n", s.Mean, s.MeanAbs)
+		fmt.Fprintf(b, "  abs delta p50 %g, p90 %g, p99 %g, max %g\n", s.P50, s.P90, s.P99, s.Max)
+		fmt.Fprintf(b, "  max prediction delta %g\n", s.MaxProba)
+	}
+	return b.Flush()
+}
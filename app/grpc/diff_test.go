@@ -0,0 +1,160 @@
+package serving
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+)
+
+func TestDiffModels(t *testing.T) {
+	before, err := parse(&[]string{
+		"0:bias:-1",
+		"1:geo=us:1",
+		"2:geo=gb:0.5",
+		"3:geo=fit.other:-1",
+		"4:zone_id=10:0.25",
+	}, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := parse(&[]string{
+		"0:bias:-1",
+		"1:geo=us:1.5",
+		"2:geo=de:0.125",
+		"3:geo=fit.other:-1",
+		"4:geoXXbrowser=usX~X8:-2",
+	}, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := DiffModels(before, after, 3)
+	if strings.Join(d.Added, ",") != "geoXXbrowser" || strings.Join(d.Removed, ",") != "zone_id" {
+		t.Errorf("added %v, removed %v", d.Added, d.Removed)
+	}
+
+	vocab := make(map[string]VocabularyDiff)
+	for _, v := range d.Vocabulary {
+		vocab[v.Feature] = v
+	}
+	if v := vocab["geo"]; v.Old != 2 || v.New != 2 || v.Added != 1 || v.Removed != 1 {
+		t.Errorf("geo vocabulary %+v", v)
+	}
+	if v := vocab["zone_id"]; v.Old != 1 || v.New != 0 || v.Removed != 1 {
+		t.Errorf("zone_id vocabulary %+v", v)
+	}
+	if v := vocab["browser"]; v.Old != 0 || v.New != 1 || v.Added != 1 {
+		t.Errorf("browser vocabulary %+v", v)
+	}
+
+	// geo=de and zone_id=10 are changed too, but deltas are smaller,
+	// equal deltas are ordered by key
+	if d.Changed != 5 || len(d.Top) != 3 {
+		t.Fatalf("changed %d, top %v", d.Changed, d.Top)
+	}
+	expected := []CoefficientDiff{
+		{Key: "geoXXbrowser=usX~X8", New: -2, Added: true},
+		{Key: "geo=gb", Old: 0.5, Removed: true},
+		{Key: "geo=us", Old: 1, New: 1.5},
+	}
+	for i, c := range expected {
+		if d.Top[i] != c {
+			t.Errorf("top[%d] %+v != %+v", i, d.Top[i], c)
+		}
+	}
+
+	reqs := []*pb.Request{
+		{Geo: "us", Browser: 8},
+		{Geo: "gb", ZoneId: 10},
+		{Geo: "fr"},
+	}
+	s := CompareScores(before, after, reqs)
+	// deltas are -1.5, -1.75 and 0
+	if s.Requests != 3 || s.Failed != 0 || math.Abs(s.Mean-(-3.25/3)) > 1e-12 ||
+		s.P50 != 1.5 || s.Max != 1.75 {
+		t.Errorf("unexpected score changes %+v", s)
+	}
+	// the largest one is of gb: score -0.25 falls to -2 by fit.other
+	if expected := Sigmoid(-0.25) - Sigmoid(-2); math.Abs(s.MaxProba-expected) > 1e-12 {
+		t.Errorf("max proba delta %v != %v", s.MaxProba, expected)
+	}
+
+	d.Scores = s
+	var buf bytes.Buffer
+	if err := d.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"variables: 1 added, 1 removed",
+		"  + geoXXbrowser",
+		"  geo: 2 -> 2 (+1 -1)",
+		"coefficients: 5 changed, top 3 by delta:",
+		"    geo=us: 1 -> 1.5 (+0.5)",
+		"  - geo=gb: 0.5",
+		"scores: 3 requests, 0 failed",
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("report has no %q:\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestDiffClasses(t *testing.T) {
+	lines := strings.Split(classesModel, "\n")
+	before, err := parse(&lines, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines = strings.Split(strings.Replace(classesModel, "1:geo=us:1,0.25,-1", "1:geo=us:1,0.5,-1", 1), "\n")
+	after, err := parse(&lines, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := DiffModels(before, after, 10)
+	if d.Changed != 1 || d.Top[0].Key != "geo=us[video]" || d.Top[0].Delta() != 0.25 {
+		t.Errorf("unexpected diff %+v", d.Top)
+	}
+	s := CompareScores(before, after, []*pb.Request{{Geo: "us"}, {Geo: "de"}})
+	if s.Max != 0.25 || s.P50 != 0 {
+		t.Errorf("unexpected score changes %+v", s)
+	}
+
+	plain, _ := parse(&[]string{"0:geo=us:1"}, DefaultSchema())
+	if s := CompareScores(plain, after, []*pb.Request{{Geo: "us"}}); s.Failed != 1 {
+		t.Errorf("multi-class model is compared with plain one: %+v", s)
+	}
+}
+
+func TestLoadRequests(t *testing.T) {
+	file, err := ioutil.TempFile("", "goinfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	writeModel(t, file.Name(), `{"geo": "us", "browser": 8, "features": {"hour": "7"}}
+
+{"time": "2020-01-01T00:00:00Z", "model": "default", "request": {"geo": "gb", "zone_id": 10}, "proba": 0.5}
+{"geo": "de", "osVersion": "mac", "click": true}
+`)
+	reqs, err := LoadRequests(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reqs) != 3 || reqs[0].Geo != "us" || reqs[0].Browser != 8 || reqs[0].Features["hour"] != "7" ||
+		reqs[1].Geo != "gb" || reqs[1].ZoneId != 10 || reqs[2].OsVersion != "mac" {
+		t.Errorf("unexpected requests %v", reqs)
+	}
+
+	writeModel(t, file.Name(), "{\"geo\": \"us\"}\n{\"geo\": 1}\n")
+	if _, err := LoadRequests(file.Name()); err == nil || !strings.Contains(err.Error(), ":2: ") {
+		t.Errorf("expected error at line 2, got %v", err)
+	}
+}
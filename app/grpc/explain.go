@@ -0,0 +1,140 @@
+package serving
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/go-code/goinfer/api"
+	"github.com/go-code/goinfer/app/metrics"
+)
+
+// Explain predicts probability like PredictProba and reports
+// contribution of every variable, it's meant for debugging
+// of individual requests, not for serving traffic
+func (inf *Inferencer) Explain(c context.Context,
+	req *pb.Request) (*pb.ExplainResponse, error) {
+
+	now := time.Now()
+	h, variant, err := inf.registry.Route(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		metrics.ProbabilityLatency("explain", h.name, variant,
+			time.Since(now).Seconds())
+	}()
+
+	m := h.Current()
+	if err := singleClass(h.name, m); err != nil {
+		return nil, err
+	}
+	logit, err := m.score(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// prediction of identity and log links isn't probability,
+	// so there is nothing before calibration to report
+	var raw float64
+	if probabilityLinks[m.link] {
+		raw = linkFuncs[m.link](logit)
+	}
+	return &pb.ExplainResponse{
+		ModelName:     h.name,
+		Variant:       variant,
+		Bias:          m.bias,
+		Logit:         logit,
+		Proba:         h.output(m, logit),
+		RawProba:      raw,
+		Contributions: m.explain(req),
+	}, nil
+}
+
+// explain lists contributions of variables to score, they
+// are found by maps of model, so explain is independent
+// of compiled representation. Contributions are sorted
+// by variable name
+func (m *Model) explain(req *pb.Request) []*pb.Contribution {
+	var contributions []*pb.Contribution
+
+	for v := range m.variables {
+		vals, missing := m.requestValues(v, req)
+		c := &pb.Contribution{
+			Variable: m.labels[v],
+			Value:    strings.Join(vals, FeatureValueSeparator),
+		}
+
+		coef, ok := 0.0, false
+		if !missing {
+			if value, err := v.makeValue(req, m.schema, &m.values); err == nil {
+				coef, ok = m.coef[v][value]
+			}
+		}
+		if ok {
+			c.Coefficient = coef
+		} else {
+			c.Coefficient = m.other[v]
+			c.Fallback = true
+		}
+		contributions = append(contributions, c)
+	}
+
+	for f, w := range m.weights {
+		c := &pb.Contribution{
+			Variable: string(m.schema.Name(f)),
+			Weight:   w,
+		}
+		if x, ok := m.schema.number(f, req); ok {
+			c.Value = strconv.FormatFloat(x, 'g', -1, 64)
+			c.Coefficient = w * x
+		} else {
+			c.Value = m.schema.raw(m.schema.features[f], req)
+			c.Fallback = true
+		}
+		contributions = append(contributions, c)
+	}
+
+	if h := m.hashing; h != nil {
+		for i, v := range h.variables {
+			vals, missing := m.requestValues(v, req)
+			c := &pb.Contribution{
+				Variable: v.fileName(m.schema),
+				Value:    strings.Join(vals, FeatureValueSeparator),
+				Hashed:   true,
+				Fallback: missing,
+			}
+			if !missing {
+				sum := h.seed
+				sum.writeString(h.prefixes[i] + c.Value)
+				c.Coefficient = h.weights[sum.sum%uint64(len(h.weights))]
+			}
+			contributions = append(contributions, c)
+		}
+	}
+
+	sort.Slice(contributions, func(i, j int) bool {
+		return contributions[i].Variable < contributions[j].Variable
+	})
+	return contributions
+}
+
+// requestValues fetches values of variable features as they are
+// written in model file, missing values are raw ones from request
+func (m *Model) requestValues(v Variable, req *pb.Request) ([]string, bool) {
+	vals := make([]string, v.size)
+	missing := false
+	for i := range vals {
+		f := v.feature(i)
+		b, ok := m.schema.appendValue(nil, f, req)
+		if !ok {
+			missing = true
+			vals[i] = m.schema.raw(m.schema.features[f], req)
+			continue
+		}
+		vals[i] = string(b)
+	}
+	return vals, missing
+}
@@ -0,0 +1,86 @@
+package serving
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+)
+
+func TestExplain(t *testing.T) {
+	inf := testInferencer(testHolder(t, "default", strings.Join([]string{
+		"0:bias:-1",
+		"1:geo=us:0.5",
+		"2:geo=fit.other:-0.25",
+		"3:geoXXbrowser=usX~X8:0.125",
+		"4:buckets=hour:6,12",
+		"5:hour=1:0.75",
+		"6:bid_floor:0.5",
+	}, "\n")))
+
+	resp, err := inf.Explain(context.Background(), &pb.Request{
+		Geo:      "de",
+		Browser:  8,
+		Features: map[string]string{"hour": "7", "bid_floor": "3"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []pb.Contribution{
+		{Variable: "bid_floor", Value: "3", Coefficient: 1.5, Weight: 0.5},
+		{Variable: "geo", Value: "de", Coefficient: -0.25, Fallback: true},
+		{Variable: "geoXXbrowser", Value: "deX~X8", Fallback: true},
+		{Variable: "hour", Value: "1", Coefficient: 0.75},
+	}
+	if len(resp.Contributions) != len(expected) {
+		t.Fatalf("unexpected contributions %v", resp.Contributions)
+	}
+
+	sum := resp.Bias
+	for i, c := range resp.Contributions {
+		e := expected[i]
+		if c.Variable != e.Variable || c.Value != e.Value || c.Coefficient != e.Coefficient ||
+			c.Fallback != e.Fallback || c.Weight != e.Weight {
+			t.Errorf("contribution %v != %v", c, &e)
+		}
+		sum += c.Coefficient
+	}
+	if resp.Logit != sum || resp.Logit != -1+1.5-0.25+0.75 {
+		t.Errorf("logit %v doesn't match contributions %v", resp.Logit, sum)
+	}
+
+	predicted, _ := inf.PredictProba(context.Background(), &pb.Request{
+		Geo:      "de",
+		Browser:  8,
+		Features: map[string]string{"hour": "7", "bid_floor": "3"},
+	})
+	if resp.Proba != predicted.Proba {
+		t.Errorf("proba %v != %v", resp.Proba, predicted.Proba)
+	}
+}
+
+func TestExplainHashed(t *testing.T) {
+	h := fnv.New32a()
+	h.Write([]byte("geoXXbrowser=usX~X8"))
+	bucket := h.Sum32() % 16
+
+	m := testHolder(t, "default", strings.Join([]string{
+		"#hash=fnv1a32",
+		"#hash_buckets=16",
+		"#variables=geoXXbrowser",
+		fmt.Sprintf("0:h=%d:0.5", bucket),
+	}, "\n")).Current()
+
+	contributions := m.explain(&pb.Request{Geo: "us", Browser: 8})
+	if len(contributions) != 1 {
+		t.Fatalf("unexpected contributions %v", contributions)
+	}
+	c := contributions[0]
+	if c.Variable != "geoXXbrowser" || c.Value != "usX~X8" || !c.Hashed || c.Coefficient != 0.5 {
+		t.Errorf("unexpected contribution %v", c)
+	}
+}
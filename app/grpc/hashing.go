@@ -0,0 +1,157 @@
+package serving
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	pb "github.com/go-code/goinfer/api"
+)
+
+// maxHashBuckets limits size of weight vector of hashed model
+const maxHashBuckets = 1 << 28
+
+// hashFuncs are hash functions which can be declared in model header
+var hashFuncs = map[string]hasher{
+	"fnv1a64": {sum: 14695981039346656037, prime: 1099511628211, mask: math.MaxUint64},
+	"fnv1a32": {sum: 2166136261, prime: 16777619, mask: math.MaxUint32},
+}
+
+// hasher is streaming FNV-1a hash, unlike hash/fnv
+// it's a value and never allocates
+type hasher struct {
+	sum, prime, mask uint64
+}
+
+func (h *hasher) writeString(s string) {
+	for i := 0; i < len(s); i++ {
+		h.sum = ((h.sum ^ uint64(s[i])) * h.prime) & h.mask
+	}
+}
+
+func (h *hasher) write(b []byte) {
+	for _, c := range b {
+		h.sum = ((h.sum ^ uint64(c)) * h.prime) & h.mask
+	}
+}
+
+// Hashing is the model mode for unbounded vocabularies:
+// variable values are hashed into fixed-size weight vector
+// instead of being enumerated.
+//
+// Key of value is formatted as in text model file, e.g.
+// "geoXXbrowser=usX~X8", so trainer and server agree on it.
+// Header of model declares hash function, number of buckets
+// and variables
+//
+//	#hash=fnv1a64
+//	#hash_buckets=1048576
+//	#variables=zone_id,banner_id,geoXXbrowser
+//	0:h=<bucket>:<coefficient>
+type Hashing struct {
+	name      string
+	seed      hasher
+	weights   []float64
+	variables []Variable
+	// prefixes are "<variable>=" parts of keys
+	prefixes []string
+}
+
+// hashingFromHeader reads hashing mode from model header,
+// nil is returned if model isn't hashed
+func hashingFromHeader(header *modelHeader, schema *Schema) (*Hashing, *ModelError) {
+	name, ok := header.values["hash"]
+	if !ok {
+		for _, key := range []string{"hash_buckets", "variables"} {
+			if _, ok := header.values[key]; ok {
+				return nil, header.fail(key, "%s requires hash", key)
+			}
+		}
+		return nil, nil
+	}
+
+	seed, ok := hashFuncs[name]
+	if !ok {
+		return nil, header.fail("hash", "unknown hash function %q", name)
+	}
+
+	buckets, err := strconv.ParseUint(header.values["hash_buckets"], 10, 32)
+	if err != nil || buckets == 0 || buckets > maxHashBuckets {
+		return nil, header.fail("hash_buckets",
+			"hash_buckets must be in range [1, %d]", maxHashBuckets)
+	}
+
+	h := &Hashing{
+		name:    name,
+		seed:    seed,
+		weights: make([]float64, buckets),
+	}
+
+	if header.values["variables"] == "" {
+		return nil, header.fail("variables", "hashed model requires variables")
+	}
+
+	seen := make(map[Variable]bool)
+	for _, item := range strings.Split(header.values["variables"], ",") {
+		names := strings.Split(strings.TrimSpace(item), FeatureNameSeparator)
+		types := make([]FeatureName, len(names))
+		if err := typelook(schema, names, types); err != nil {
+			return nil, header.fail("variables", "%v", err)
+		}
+		v := newVariable(types...)
+		if seen[v] {
+			return nil, header.fail("variables", "duplicate variable %s", item)
+		}
+		seen[v] = true
+		h.variables = append(h.variables, v)
+		h.prefixes = append(h.prefixes, v.fileName(schema)+"=")
+	}
+	return h, nil
+}
+
+// set stores weight of bucket from "h=<bucket>" line
+func (h *Hashing) set(feature string, c float64, seen map[uint32]bool) error {
+	b, err := strconv.ParseUint(strings.TrimPrefix(feature, "h="), 10, 32)
+	if err != nil || b >= uint64(len(h.weights)) {
+		return fmt.Errorf("bucket %q is out of range [0, %d)", feature, len(h.weights))
+	}
+	if seen[uint32(b)] {
+		return fmt.Errorf("duplicate key %s", feature)
+	}
+	seen[uint32(b)] = true
+	h.weights[b] = c
+	return nil
+}
+
+// score sums weights of hashed variable values of request,
+// values are hashed as they are read, so nothing is allocated
+func (h *Hashing) score(req *pb.Request, schema *Schema, stats *scoreStats) float64 {
+	var score float64
+	stats.total += len(h.variables)
+	var buf [maxValueLen]byte
+	for i, v := range h.variables {
+		sum := h.seed
+		sum.writeString(h.prefixes[i])
+
+		missing := false
+		for j := 0; j < int(v.size); j++ {
+			if j > 0 {
+				sum.writeString(FeatureValueSeparator)
+			}
+			b, ok := schema.appendValue(buf[:0], v.feature(j), req)
+			if !ok {
+				missing = true
+				break
+			}
+			sum.write(b)
+		}
+		if missing {
+			stats.variance += priorVariance
+			continue
+		}
+		stats.found++
+		score += h.weights[sum.sum%uint64(len(h.weights))]
+	}
+	return score
+}
@@ -0,0 +1,72 @@
+package serving
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+)
+
+func TestHashedModel(t *testing.T) {
+	const buckets = 1024
+	bucket := func(key string) uint64 {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		return h.Sum64() % buckets
+	}
+
+	h := testHolder(t, "default", strings.Join([]string{
+		"#hash=fnv1a64",
+		fmt.Sprintf("#hash_buckets=%d", buckets),
+		"#variables=geo, geoXXbrowser",
+		"0:bias:-1",
+		fmt.Sprintf("1:h=%d:0.5", bucket("geo=us")),
+		fmt.Sprintf("2:h=%d:0.25", bucket("geoXXbrowser=usX~X8")),
+	}, "\n"))
+	m := h.Current()
+
+	if m.Variables() != 2 {
+		t.Errorf("expected 2 variables, got %d", m.Variables())
+	}
+
+	score, err := m.score(&pb.Request{Geo: "us", Browser: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score != -1+0.5+0.25 {
+		t.Errorf("score %v != %v", score, -1+0.5+0.25)
+	}
+}
+
+func TestHashedModelErrors(t *testing.T) {
+	cases := []struct {
+		lines  []string
+		line   int
+		reason string
+	}{
+		{[]string{"#hash=md5", "#hash_buckets=8", "#variables=geo"}, 1, "unknown hash function"},
+		{[]string{"#hash=fnv1a64", "#hash_buckets=0", "#variables=geo"}, 2, "hash_buckets must be in range"},
+		{[]string{"#hash=fnv1a64", "#hash_buckets=8"}, 2, "hashed model requires variables"},
+		{[]string{"#hash_buckets=8"}, 1, "hash_buckets requires hash"},
+		{[]string{"#hash=fnv1a64", "#hash_buckets=8", "#variables=geo", "#hash=fnv1a32"}, 4, "duplicate header hash"},
+		{[]string{"#color=red"}, 1, "expected #<key>=<value> header"},
+		{[]string{"#hash=fnv1a64", "#hash_buckets=8", "#variables=geo", "0:h=8:1"}, 4, "out of range"},
+		{[]string{"#hash=fnv1a64", "#hash_buckets=8", "#variables=geo", "0:h=1:1", "1:h=1:1"}, 5, "duplicate key"},
+		{[]string{"#hash=fnv1a64", "#hash_buckets=8", "#variables=geo", "0:geo=us:1"}, 4, "model is hashed"},
+	}
+
+	for _, c := range cases {
+		_, err := parse(&c.lines, DefaultSchema())
+
+		errs, ok := err.(ModelErrors)
+		if !ok || len(errs) != 1 {
+			t.Errorf("%v: expected single ModelError, got %v", c.lines, err)
+			continue
+		}
+		if errs[0].Line != c.line || !strings.Contains(errs[0].Reason, c.reason) {
+			t.Errorf("%v: expected %q at line %d, got %v", c.lines, c.reason, c.line, errs[0])
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package serving
+
+import (
+	"fmt"
+	"strings"
+)
+
+// headerKeys are keys which can be declared in model header
+var headerKeys = map[string]bool{
+	"classes":      true,
+	"hash":         true,
+	"hash_buckets": true,
+	"link":         true,
+	"variables":    true,
+}
+
+// modelHeader keeps "#<key>=<value>" lines at the top of model file,
+// they declare how the rest of the file is interpreted
+type modelHeader struct {
+	values map[string]string
+	lines  map[string]int
+	last   int
+}
+
+// parseHeader reads leading header lines and returns
+// the number of lines it consumed
+func parseHeader(lines []string) (*modelHeader, int, ModelErrors) {
+	header := &modelHeader{
+		values: make(map[string]string),
+		lines:  make(map[string]int),
+	}
+	var errs ModelErrors
+
+	n := 0
+	for ; n < len(lines); n++ {
+		line := strings.TrimSpace(lines[n])
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+
+		pair := strings.SplitN(line[1:], "=", 2)
+		if len(pair) != 2 || !headerKeys[pair[0]] {
+			errs = append(errs, &ModelError{
+				Line:   n + 1,
+				Reason: fmt.Sprintf("expected #<key>=<value> header, got %q", line),
+			})
+			continue
+		}
+		if _, dup := header.values[pair[0]]; dup {
+			errs = append(errs, &ModelError{
+				Line:   n + 1,
+				Reason: fmt.Sprintf("duplicate header %s", pair[0]),
+			})
+			continue
+		}
+		header.values[pair[0]] = pair[1]
+		header.lines[pair[0]] = n + 1
+		header.last = n + 1
+	}
+	return header, n, errs
+}
+
+// fail reports problem of header key at its line,
+// missing keys are reported at the end of header
+func (h *modelHeader) fail(key, format string, args ...interface{}) *ModelError {
+	line, ok := h.lines[key]
+	if !ok {
+		line = h.last
+	}
+	return &ModelError{
+		Line:   line,
+		Reason: fmt.Sprintf(format, args...),
+	}
+}
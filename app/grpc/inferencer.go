@@ -2,17 +2,21 @@ package serving
 
 import (
 	"context"
+	"log"
+	"sync"
 	"time"
 
 	pb "github.com/go-code/goinfer/api"
 	"github.com/go-code/goinfer/app/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Inferencer is simple implementation of grpc
 // InferencerService interface described in protobuf file.
 //
-// In general, it is container for variables, values
-// and coefficients of trained model
+// In general, it is container for trained models, each of them
+// consists of variables, values and coefficients (see Model)
 //
 // variables object is used for fetching specific fields from
 // grpc request, converting them to appropriate type
@@ -21,44 +25,207 @@ import (
 // and used for fast coefficient access for that feature
 //
 // coef stores coefficients of trained model
+//
+// Models are kept in registry by name, request chooses
+// model or experiment with model_name field
 type Inferencer struct {
-	variables VariableSet
-	values    KVstore
-	coef      CoeffStore
+	registry *Registry
+
+	// streamWindow limits number of computed responses
+	// waiting to be sent to the client of single stream
+	streamWindow int
+	done         chan struct{}
+	stopOnce     sync.Once
+
+	// confidence is definition of response confidence
+	confidence string
 }
 
 // NewInferencer produces the instance of of server
 func NewInferencer(config Yaml) *Inferencer {
-	initFeatureNameFromString()
-	obj := Inferencer{}
-	obj.loadModel(config)
-	return &obj
+	registry, err := NewRegistry(config)
+	if err != nil {
+		log.Fatalf("Failed to load models: %v", err)
+	}
+
+	window := defaultStreamWindow
+	if v, ok := config["stream_window"]; ok {
+		if window, ok = v.(int); !ok || window <= 0 {
+			log.Fatalf("stream_window must be positive integer, got %v", v)
+		}
+	}
+
+	confidence, err := confidenceFromConfig(config)
+	if err != nil {
+		log.Fatalf("Bad config: %v", err)
+	}
+
+	return &Inferencer{
+		registry:     registry,
+		streamWindow: window,
+		done:         make(chan struct{}),
+		confidence:   confidence,
+	}
+}
+
+// Shutdown asks long-living streams to finish,
+// it must be called before grpc server is stopped gracefully
+func (inf *Inferencer) Shutdown() {
+	inf.stopOnce.Do(func() { close(inf.done) })
+}
+
+// Watch reloads models when their files change.
+// It blocks until context is canceled
+func (inf *Inferencer) Watch(ctx context.Context, interval time.Duration) {
+	inf.registry.Watch(ctx, interval)
+}
+
+// Run starts background workers (e.g. shadow scoring).
+// It blocks until context is canceled and workers are stopped
+func (inf *Inferencer) Run(ctx context.Context) {
+	inf.registry.Run(ctx)
+}
+
+// ReloadModel is admin handle which forces model reload
+func (inf *Inferencer) ReloadModel(c context.Context,
+	req *pb.ReloadRequest) (*pb.ReloadResponse, error) {
+
+	h, err := inf.registry.Get(req.GetModelName())
+	if err != nil {
+		return nil, err
+	}
+	if err := h.Reload(); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"failed to reload model: %v", err)
+	}
+	m := h.Current()
+	return &pb.ReloadResponse{
+		Model:     m.path,
+		LoadedAt:  m.loadedAt.Unix(),
+		ModelName: h.name,
+	}, nil
 }
 
 // PredictProba is the main function of this project.
 // It predicts probability of outcome given input request
 //
-// Currently this function is supposed to compute probabilities
-// for logistic regression using formula
+// It computes probabilities for logistic (or probit) regression
+// using formula
 //	 p := sigmoid ( sum of cofficients )
-// TODO: But it can be easily transformed to generalized response
-// predictor for any linear model
+// Other linear models are served by Predict
 func (inf *Inferencer) PredictProba(c context.Context,
 	req *pb.Request) (*pb.Response, error) {
 
+	resp, err := inf.predict(req)
+	if err != nil {
+		return &pb.Response{}, err
+	}
+	return resp, nil
+}
+
+// Predict computes score of any generalized linear model
+// and transforms it by link function of the model
+func (inf *Inferencer) Predict(c context.Context,
+	req *pb.Request) (*pb.Prediction, error) {
+
+	now := time.Now()
+	var model, variant string
+	defer func() {
+		metrics.ProbabilityLatency("predict", model, variant,
+			time.Since(now).Seconds())
+	}()
+
+	h, variant, err := inf.registry.Route(req)
+	if err != nil {
+		return nil, err
+	}
+	model = h.name
+
+	m := h.Current()
+	if err := singleClass(model, m); err != nil {
+		return nil, err
+	}
+	score, stats, err := m.scoreStats(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.Prediction{
+		Score:      score,
+		Prediction: h.output(m, score),
+		Link:       m.link,
+		Confidence: confidence(inf.confidence, stats),
+		ModelName:  model,
+		Variant:    variant,
+	}, nil
+}
+
+// PredictProbaBatch predicts probabilities for several requests at once.
+// Responses go in the order of requests, failure of single request
+// is reported in its item and doesn't fail the whole batch
+func (inf *Inferencer) PredictProbaBatch(c context.Context,
+	req *pb.BatchRequest) (*pb.BatchResponse, error) {
+
 	now := time.Now()
 	defer func() {
-		metrics.ProbabilityLatency("predict_proba", time.Since(now).Seconds())
+		metrics.ProbabilityLatency("predict_proba_batch", "", "",
+			time.Since(now).Seconds())
 	}()
 
-	var score float64
-	for variable := range inf.variables {
-		value, err := variable.makeValue(req, &inf.values)
+	items := make([]*pb.BatchItem, len(req.GetRequests()))
+	for i, r := range req.GetRequests() {
+		if err := c.Err(); err != nil {
+			return nil, err
+		}
+
+		resp, err := inf.predict(r)
 		if err != nil {
-			return &pb.Response{}, err
+			st := status.Convert(err)
+			items[i] = &pb.BatchItem{Code: int32(st.Code()), Error: st.Message()}
+			continue
 		}
-		coef := inf.coef[variable][value]
-		score += coef
+		items[i] = &pb.BatchItem{Response: resp}
 	}
-	return &pb.Response{Proba: Sigmoid(score), Confidence: 1.0}, nil
+	return &pb.BatchResponse{Items: items}, nil
+}
+
+// predict routes request to model and computes probability
+func (inf *Inferencer) predict(req *pb.Request) (*pb.Response, error) {
+	now := time.Now()
+	var model, variant string
+	defer func() {
+		metrics.ProbabilityLatency("predict_proba", model, variant,
+			time.Since(now).Seconds())
+	}()
+
+	h, variant, err := inf.registry.Route(req)
+	if err != nil {
+		return nil, err
+	}
+	model = h.name
+
+	m := h.Current()
+	if err := singleClass(model, m); err != nil {
+		return nil, err
+	}
+	if !probabilityLinks[m.link] {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"model %s has %s link, it's served by Predict", model, m.link)
+	}
+	score, stats, err := m.scoreStats(req)
+	if err != nil {
+		return nil, err
+	}
+	proba := h.output(m, score)
+
+	if s := inf.registry.Shadow(model); s != nil {
+		s.Submit(req, proba)
+	}
+
+	return &pb.Response{
+		Proba:      proba,
+		Confidence: confidence(inf.confidence, stats),
+		ModelName:  model,
+		Variant:    variant,
+	}, nil
 }
@@ -0,0 +1,54 @@
+package serving
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+	"google.golang.org/grpc/codes"
+)
+
+func testInferencer(holders ...*ModelHolder) *Inferencer {
+	r := &Registry{holders: make(map[string]*ModelHolder)}
+	for _, h := range holders {
+		r.holders[h.name] = h
+	}
+	if len(holders) > 0 {
+		r.defaultName = holders[0].name
+	}
+	return &Inferencer{
+		registry:     r,
+		streamWindow: defaultStreamWindow,
+		done:         make(chan struct{}),
+		confidence:   defaultConfidence,
+	}
+}
+
+func TestPredictProbaBatch(t *testing.T) {
+	inf := testInferencer(testHolder(t, "default", "0:geo=us:1\n1:geo=gb:-1\n"))
+
+	resp, err := inf.PredictProbaBatch(context.Background(), &pb.BatchRequest{
+		Requests: []*pb.Request{
+			{Geo: "us"},
+			{Geo: "gb", ModelName: "unknown"},
+			{Geo: "gb"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := resp.GetItems()
+	if len(items) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(items))
+	}
+	if p := items[0].GetResponse().GetProba(); p != Sigmoid(1) {
+		t.Errorf("items[0] proba %v != %v", p, Sigmoid(1))
+	}
+	if items[1].GetResponse() != nil || codes.Code(items[1].GetCode()) != codes.NotFound {
+		t.Errorf("items[1] must fail with NotFound: %v", items[1])
+	}
+	if p := items[2].GetResponse().GetProba(); p != Sigmoid(-1) {
+		t.Errorf("items[2] proba %v != %v", p, Sigmoid(-1))
+	}
+}
@@ -0,0 +1,81 @@
+package serving
+
+import (
+	"context"
+	"sort"
+
+	pb "github.com/go-code/goinfer/api"
+)
+
+// GetModelInfo describes model which is being served
+func (inf *Inferencer) GetModelInfo(c context.Context,
+	req *pb.ModelInfoRequest) (*pb.ModelInfoResponse, error) {
+
+	h, err := inf.registry.Get(req.GetModelName())
+	if err != nil {
+		return nil, err
+	}
+	info := h.Current().Info()
+	info.ModelName = h.name
+	info.Calibration = h.Calibration().Info()
+	return info, nil
+}
+
+// Info describes model: its file, features and variables.
+// Variables are sorted by name
+func (m *Model) Info() *pb.ModelInfoResponse {
+	info := &pb.ModelInfoResponse{
+		ModelName: m.name,
+		Path:      m.path,
+		Checksum:  m.checksum,
+		Format:    m.format,
+		LoadedAt:  m.loadedAt.Unix(),
+		Bias:      m.bias,
+		Link:      m.link,
+	}
+	if m.classes != nil {
+		info.Classes = m.classes.labels
+	}
+
+	for i, spec := range m.schema.features {
+		f := FeatureName(i)
+		weight, ok := m.weights[f]
+		info.Features = append(info.Features, &pb.FeatureInfo{
+			Name:           string(spec.name),
+			Field:          spec.field,
+			Kind:           spec.kind,
+			VocabularySize: m.values.uniqs[f],
+			Buckets:        spec.buckets,
+			Weight:         weight,
+			HasWeight:      ok,
+		})
+	}
+
+	for v := range m.variables {
+		other, ok := m.other[v]
+		info.Variables = append(info.Variables, &pb.VariableInfo{
+			Name:         v.fileName(m.schema),
+			Features:     v.names(m.schema),
+			Coefficients: uint32(len(m.coef[v])),
+			Other:        other,
+			HasOther:     ok,
+		})
+	}
+
+	if h := m.hashing; h != nil {
+		info.Hash = h.name
+		info.HashBuckets = uint32(len(h.weights))
+		for _, v := range h.variables {
+			info.Variables = append(info.Variables, &pb.VariableInfo{
+				Name:     v.fileName(m.schema),
+				Features: v.names(m.schema),
+				Hashed:   true,
+			})
+		}
+	}
+
+	sort.Slice(info.Variables, func(i, j int) bool {
+		return info.Variables[i].Name < info.Variables[j].Name
+	})
+	return info
+}
@@ -0,0 +1,74 @@
+package serving
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetModelInfo(t *testing.T) {
+	content := strings.Join([]string{
+		"0:bias:-1.5",
+		"1:geo=us:1",
+		"2:geo=gb:2",
+		"3:geo=fit.other:-1",
+		"4:geoXXbrowser=usX~X8:0.5",
+		"5:buckets=hour:6,12",
+		"6:hour=1:0.25",
+		"7:bid_floor:0.125",
+	}, "\n")
+	inf := testInferencer(testHolder(t, "default", content))
+
+	info, err := inf.GetModelInfo(context.Background(), &pb.ModelInfoRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	if info.ModelName != "default" || info.Format != "text" ||
+		info.Checksum != hex.EncodeToString(sum[:]) || info.LoadedAt == 0 {
+		t.Errorf("unexpected model info %v", info)
+	}
+	if info.Bias != -1.5 {
+		t.Errorf("bias %v != -1.5", info.Bias)
+	}
+
+	features := make(map[string]*pb.FeatureInfo)
+	for _, f := range info.Features {
+		features[f.Name] = f
+	}
+	if f := features["geo"]; f == nil || f.VocabularySize != 2 || f.Field != "geo" {
+		t.Errorf("unexpected geo %v", f)
+	}
+	if f := features["hour"]; f == nil || f.Kind != "numeric" || len(f.Buckets) != 2 {
+		t.Errorf("unexpected hour %v", f)
+	}
+	if f := features["bid_floor"]; f == nil || !f.HasWeight || f.Weight != 0.125 {
+		t.Errorf("unexpected bid_floor %v", f)
+	}
+
+	var names []string
+	for _, v := range info.Variables {
+		names = append(names, v.Name)
+	}
+	if strings.Join(names, " ") != "geo geoXXbrowser hour" {
+		t.Errorf("unexpected variables %v", names)
+	}
+	if v := info.Variables[0]; v.Coefficients != 2 || !v.HasOther || v.Other != -1 {
+		t.Errorf("unexpected geo variable %v", v)
+	}
+	if v := info.Variables[1]; len(v.Features) != 2 || v.Coefficients != 1 || v.HasOther {
+		t.Errorf("unexpected interaction %v", v)
+	}
+
+	_, err = inf.GetModelInfo(context.Background(), &pb.ModelInfoRequest{ModelName: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+}
@@ -8,6 +8,17 @@ import (
 
 type ValueIndex map[string]uint32
 
+// MissingValueError means that feature value
+// hasn't been seen in training
+type MissingValueError struct {
+	Feature FeatureName
+	Value   string
+}
+
+func (e *MissingValueError) Error() string {
+	return fmt.Sprintf("Missing value %v", e.Value)
+}
+
 type KVstore struct {
 	store map[FeatureName]ValueIndex
 	uniqs map[FeatureName]uint32
@@ -42,14 +53,16 @@ func (s *KVstore) Set(key FeatureName, val string) (uint32, error) {
 }
 
 func (s *KVstore) Get(key FeatureName, val string) (uint32, error) {
+	// feature without values is possible when
+	// model has only "fit.other" coefficient for it
 	inner, ok := s.store[key]
 	if !ok {
-		return 0, fmt.Errorf("Missing feature %v", key)
+		return 0, &MissingValueError{Feature: key, Value: val}
 	}
 
 	no, ok := inner[val]
 	if !ok {
-		return 0, fmt.Errorf("Missing value %v", val)
+		return 0, &MissingValueError{Feature: key, Value: val}
 	}
 
 	return no, nil
@@ -0,0 +1,565 @@
+package serving
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	pb "github.com/go-code/goinfer/api"
+	"github.com/go-code/goinfer/app/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPublishInterval  = time.Second
+	defaultSnapshotInterval = 10 * time.Minute
+)
+
+// Learner updates coefficients of model by feedback of requests
+// with FTRL-Proximal, the algorithm goFTRL trains models with.
+//
+// State of each coordinate is z and n accumulators. n is count of
+// coefficient in model file (zero if there is no count), z is found
+// from coefficient and n. Model file has no z, so resume isn't exact:
+// coordinates with zero coefficient start from zero z, and numeric
+// weights have no counts, so they start from zero n. Parameters
+// of algorithm must be the ones model is trained with.
+//
+// Only coefficients used for scoring of request are updated:
+// bias, coefficients of seen values, "fit.other" of unseen ones
+// and weights of numeric features, vocabularies never grow.
+//
+// Updates are collected in memory and published as a new Model
+// every publish interval. If apply is "serving", the model replaces
+// the one of holder, otherwise it's only written to snapshot file.
+// Reload of model file drops updates which aren't saved yet
+//
+// Config format is
+//
+//	online:
+//	 - model: <model name>
+//	   alpha: <learning rate>
+//	   beta: <learning rate smoothing>
+//	   l1: <L1 regularization>
+//	   l2: <L2 regularization>
+//	   apply: <serving or shadow, default shadow>
+//	   publish_interval: <default 1s>
+//	   snapshot: <path to binary model file, optional>
+//	   snapshot_interval: <default 10m>
+type Learner struct {
+	holder           *ModelHolder
+	params           ftrl
+	serving          bool
+	publishInterval  time.Duration
+	snapshotPath     string
+	snapshotInterval time.Duration
+
+	// commitMu serializes commits, so models are built
+	// one at a time without holding mu
+	commitMu sync.Mutex
+
+	mu sync.Mutex
+	// base is the model state is built on, published is
+	// the last model built from state
+	base      *Model
+	published *Model
+	state     map[coord]*ftrlState
+	// updates are counted since base is loaded
+	updates uint64
+	// changed are coordinates updated since the last publish,
+	// unsaved is set by updates which aren't in snapshot yet
+	changed map[coord]bool
+	unsaved bool
+}
+
+// ftrl keeps parameters of FTRL-Proximal
+type ftrl struct {
+	alpha, beta, l1, l2 float64
+}
+
+type ftrlState struct {
+	z, n float64
+}
+
+// weight computes coefficient of coordinate
+func (p ftrl) weight(s ftrlState) float64 {
+	if math.Abs(s.z) <= p.l1 {
+		return 0
+	}
+	sign := 1.0
+	if s.z < 0 {
+		sign = -1
+	}
+	return -(s.z - sign*p.l1) / ((p.beta+math.Sqrt(s.n))/p.alpha + p.l2)
+}
+
+// state restores z of coefficient w with accumulator n, it's
+// the inverse of weight except for w = 0, which any |z| <= l1 gives
+func (p ftrl) state(w, n float64) ftrlState {
+	if w == 0 {
+		return ftrlState{n: n}
+	}
+	sign := 1.0
+	if w < 0 {
+		sign = -1
+	}
+	z := -w*((p.beta+math.Sqrt(n))/p.alpha+p.l2) - sign*p.l1
+	return ftrlState{z: z, n: n}
+}
+
+// update applies gradient g to coordinate
+func (p ftrl) update(s *ftrlState, g float64) {
+	w := p.weight(*s)
+	sigma := (math.Sqrt(s.n+g*g) - math.Sqrt(s.n)) / p.alpha
+	s.z += g - sigma*w
+	s.n += g * g
+}
+
+type coordKind uint8
+
+const (
+	coordBias coordKind = iota
+	coordWeight
+	coordValue
+	coordOther
+)
+
+// coord identifies coefficient of model
+type coord struct {
+	kind     coordKind
+	variable Variable
+	value    Value
+	feature  FeatureName
+}
+
+// activeCoord is coordinate used for scoring of request
+// with its feature value, it's 1 for categorical features
+type activeCoord struct {
+	coord
+	x float64
+}
+
+// Feedback updates model by outcome of request.
+// It returns probability of request before the update
+func (l *Learner) Feedback(req *pb.Request, click bool) (float64, uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sync()
+	m := l.base
+	if m.hashing != nil || m.classes != nil || m.link != linkLogistic {
+		return 0, 0, status.Errorf(codes.FailedPrecondition,
+			"model %s is not plain logistic regression, it can't be updated", l.holder.name)
+	}
+
+	active := []activeCoord{{coord: coord{kind: coordBias}, x: 1}}
+	for v := range m.variables {
+		c := coord{kind: coordOther, variable: v}
+		if value, err := v.makeValue(req, m.schema, &m.values); err == nil {
+			if _, ok := m.coef[v][value]; ok {
+				c = coord{kind: coordValue, variable: v, value: value}
+			}
+		}
+		if _, ok := m.other[v]; c.kind == coordOther && !ok {
+			// unseen value without "fit.other" contributes nothing
+			continue
+		}
+		active = append(active, activeCoord{coord: c, x: 1})
+	}
+	for f := range m.weights {
+		if x, ok := m.schema.number(f, req); ok {
+			active = append(active, activeCoord{coord: coord{kind: coordWeight, feature: f}, x: x})
+		}
+	}
+
+	states := make([]*ftrlState, len(active))
+	var score float64
+	for i, a := range active {
+		states[i] = l.stateOf(a.coord)
+		score += l.params.weight(*states[i]) * a.x
+	}
+	proba := Sigmoid(score)
+
+	y := 0.0
+	if click {
+		y = 1
+	}
+	if l.changed == nil {
+		l.changed = make(map[coord]bool)
+	}
+	for i, a := range active {
+		l.params.update(states[i], (proba-y)*a.x)
+		l.changed[a.coord] = true
+	}
+	l.updates++
+	l.unsaved = true
+	return proba, l.updates, nil
+}
+
+// sync starts over if model of holder is reloaded from file
+func (l *Learner) sync() {
+	current := l.holder.Current()
+	if current == l.base || current == l.published {
+		return
+	}
+	if len(l.changed) > 0 || l.unsaved {
+		log.Printf("Model %s is reloaded, dropping online updates", l.holder.name)
+	}
+	l.base = current
+	l.published = nil
+	l.state = make(map[coord]*ftrlState)
+	l.updates = 0
+	l.changed = nil
+	l.unsaved = false
+}
+
+// stateOf returns state of coordinate, it's restored
+// from base model when coordinate is updated first time
+func (l *Learner) stateOf(c coord) *ftrlState {
+	if s, ok := l.state[c]; ok {
+		return s
+	}
+
+	m := l.base
+	var w, n float64
+	switch c.kind {
+	case coordBias:
+		w = m.bias
+		if m.biasCount != nil {
+			n = *m.biasCount
+		}
+	case coordWeight:
+		w = m.weights[c.feature]
+	case coordValue:
+		w, n = m.coef[c.variable][c.value], m.counts[c.variable][c.value]
+	case coordOther:
+		w, n = m.other[c.variable], m.otherCounts[c.variable]
+	}
+	s := l.params.state(w, n)
+	l.state[c] = &s
+	return &s
+}
+
+// build makes model of prev with changed coefficients
+// and counts, maps of prev are copied when they change
+func (l *Learner) build(prev *Model, changes map[coord]ftrlState) *Model {
+	coef := make(CoeffStore, len(prev.coef))
+	for v, inner := range prev.coef {
+		coef[v] = inner
+	}
+	counts := make(CoeffStore, len(prev.counts))
+	for v, inner := range prev.counts {
+		counts[v] = inner
+	}
+	other := make(OtherStore, len(prev.other))
+	for v, c := range prev.other {
+		other[v] = c
+	}
+	otherCounts := make(OtherStore, len(prev.otherCounts))
+	for v, n := range prev.otherCounts {
+		otherCounts[v] = n
+	}
+	weights := make(map[FeatureName]float64, len(prev.weights))
+	for f, w := range prev.weights {
+		weights[f] = w
+	}
+	bias, hasBias, biasCount := prev.bias, prev.hasBias, prev.biasCount
+
+	copied := make(map[Variable]bool)
+	for c, s := range changes {
+		w := l.params.weight(s)
+		switch c.kind {
+		case coordBias:
+			n := s.n
+			bias, hasBias, biasCount = w, true, &n
+		case coordWeight:
+			weights[c.feature] = w
+		case coordOther:
+			other[c.variable] = w
+			otherCounts[c.variable] = s.n
+		case coordValue:
+			if !copied[c.variable] {
+				coef[c.variable] = copyValues(coef[c.variable])
+				counts[c.variable] = copyValues(counts[c.variable])
+				copied[c.variable] = true
+			}
+			coef[c.variable][c.value] = w
+			counts[c.variable][c.value] = s.n
+		}
+	}
+
+	m := newModel(prev.schema, prev.variables, prev.values, coef, other)
+	m.bias = bias
+	m.hasBias = hasBias
+	m.weights = weights
+	m.link = prev.link
+	m.counts = counts
+	m.otherCounts = otherCounts
+	m.biasCount = biasCount
+	m.splitOther = prev.splitOther
+	m.compiled = compile(m)
+
+	// model doesn't match any file, so it has no checksum
+	m.name = prev.name
+	m.path = prev.path
+	m.format = prev.format
+	m.loadedAt = time.Now()
+	return m
+}
+
+func copyValues(values ValueStore) ValueStore {
+	c := make(ValueStore, len(values))
+	for value, x := range values {
+		c[value] = x
+	}
+	return c
+}
+
+// publish builds model from updates, it replaces
+// the model of holder if updates apply to serving
+func (l *Learner) publish() *Model {
+	m, _ := l.commit(false)
+	return m
+}
+
+// commit publishes updates. If save is set, it also returns and
+// clears unsaved flag, so the flag matches the published model
+// even if feedback arrives right after it. States of changed
+// coordinates are copied under lock and model is built without
+// it, so feedback isn't blocked while model is compiled
+func (l *Learner) commit(save bool) (*Model, bool) {
+	l.commitMu.Lock()
+	defer l.commitMu.Unlock()
+
+	l.mu.Lock()
+	l.sync()
+	unsaved := false
+	if save {
+		unsaved, l.unsaved = l.unsaved, false
+	}
+	if len(l.changed) == 0 {
+		m := l.published
+		l.mu.Unlock()
+		return m, unsaved
+	}
+	base, prev := l.base, l.published
+	if prev == nil {
+		prev = base
+	}
+	changes := make(map[coord]ftrlState, len(l.changed))
+	for c := range l.changed {
+		changes[c] = *l.state[c]
+	}
+	l.changed = nil
+	l.mu.Unlock()
+
+	m := l.build(prev, changes)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.serving {
+		// model isn't reloaded from file between sync and store,
+		// otherwise reloaded model is replaced by outdated one
+		l.holder.reloadMu.Lock()
+		defer l.holder.reloadMu.Unlock()
+	}
+	l.sync()
+	if l.base != base {
+		// updates are dropped with reloaded model
+		return nil, false
+	}
+	l.published = m
+	if l.serving {
+		l.holder.model.Store(m)
+	}
+	return m, unsaved
+}
+
+// Model returns model with all updates applied,
+// it's the model of holder if there are no updates
+func (l *Learner) Model() *Model {
+	if m := l.publish(); m != nil {
+		return m
+	}
+	return l.holder.Current()
+}
+
+// snapshot writes updated model to snapshot file. Model
+// is written to temporary file first, so readers of snapshot
+// never see partially written model
+func (l *Learner) snapshot() error {
+	m, unsaved := l.commit(true)
+	if m == nil || !unsaved {
+		return nil
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(l.snapshotPath), ".snapshot")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := WriteBinary(file, m); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), l.snapshotPath)
+}
+
+// Run publishes updates and writes snapshots. It blocks
+// until context is canceled, final snapshot is written then
+func (l *Learner) Run(ctx context.Context) {
+	publish := time.NewTicker(l.publishInterval)
+	defer publish.Stop()
+
+	var snapshot <-chan time.Time
+	if l.snapshotPath != "" {
+		ticker := time.NewTicker(l.snapshotInterval)
+		defer ticker.Stop()
+		snapshot = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// updates since the last snapshot aren't lost on shutdown
+			if l.snapshotPath != "" {
+				l.save()
+			}
+			return
+		case <-publish.C:
+			l.publish()
+		case <-snapshot:
+			l.save()
+		}
+	}
+}
+
+// save writes snapshot, updates stay unsaved if it fails
+func (l *Learner) save() {
+	if err := l.snapshot(); err != nil {
+		log.Printf("Failed to write snapshot of model %s to %s: %v",
+			l.holder.name, l.snapshotPath, err)
+		l.mu.Lock()
+		l.unsaved = true
+		l.mu.Unlock()
+	}
+}
+
+// Feedback reports outcome of request, it updates model
+// which has served the request if online learning is enabled
+func (inf *Inferencer) Feedback(c context.Context,
+	req *pb.FeedbackRequest) (*pb.FeedbackResponse, error) {
+
+	now := time.Now()
+	var model, variant string
+	defer func() {
+		metrics.ProbabilityLatency("feedback", model, variant,
+			time.Since(now).Seconds())
+	}()
+
+	if req.GetRequest() == nil {
+		return nil, status.Error(codes.InvalidArgument, "request is required")
+	}
+	h, variant, err := inf.registry.Route(req.GetRequest())
+	if err != nil {
+		return nil, err
+	}
+	model = h.name
+
+	l := inf.registry.Learner(model)
+	if l == nil {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"online learning is not enabled for model %s", model)
+	}
+	proba, updates, err := l.Feedback(req.GetRequest(), req.GetClick())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.FeedbackResponse{
+		Proba:     proba,
+		ModelName: model,
+		Variant:   variant,
+		Updates:   updates,
+	}, nil
+}
+
+func learnersFromConfig(config Yaml,
+	holders map[string]*ModelHolder) (map[string]*Learner, error) {
+
+	learners := make(map[string]*Learner)
+
+	items, _ := config["online"].([]interface{})
+	for i, item := range items {
+		entry, ok := asYaml(item)
+		if !ok {
+			return nil, fmt.Errorf("online[%d]: expected model and parameters", i)
+		}
+
+		model, _ := entry["model"].(string)
+		h, ok := holders[model]
+		if !ok {
+			return nil, fmt.Errorf("online[%d]: unknown model %q", i, model)
+		}
+		if _, dup := learners[model]; dup {
+			return nil, fmt.Errorf("online[%d]: duplicate model %q", i, model)
+		}
+
+		// parameters have no defaults, they must match training
+		var params [4]float64
+		for j, key := range []string{"alpha", "beta", "l1", "l2"} {
+			x, ok := asFloat(entry[key])
+			if !ok || x < 0 || (key == "alpha" && x == 0) {
+				return nil, fmt.Errorf("online[%d]: %s must be non-negative number, alpha must be positive", i, key)
+			}
+			params[j] = x
+		}
+
+		l := &Learner{
+			holder:           h,
+			params:           ftrl{alpha: params[0], beta: params[1], l1: params[2], l2: params[3]},
+			publishInterval:  defaultPublishInterval,
+			snapshotInterval: defaultSnapshotInterval,
+			state:            make(map[coord]*ftrlState),
+		}
+
+		switch apply, _ := entry["apply"].(string); apply {
+		case "serving":
+			l.serving = true
+		case "shadow", "":
+		default:
+			return nil, fmt.Errorf("online[%d]: apply must be serving or shadow, got %q", i, apply)
+		}
+
+		for key, d := range map[string]*time.Duration{
+			"publish_interval":  &l.publishInterval,
+			"snapshot_interval": &l.snapshotInterval,
+		} {
+			v, ok := entry[key]
+			if !ok {
+				continue
+			}
+			s, _ := v.(string)
+			x, err := time.ParseDuration(s)
+			if err != nil || x <= 0 {
+				return nil, fmt.Errorf("online[%d]: bad %s %v", i, key, v)
+			}
+			*d = x
+		}
+		l.snapshotPath, _ = entry["snapshot"].(string)
+
+		learners[model] = l
+	}
+
+	return learners, nil
+}
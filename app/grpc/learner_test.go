@@ -0,0 +1,401 @@
+package serving
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/go-code/goinfer/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v2"
+)
+
+func testLearner(h *ModelHolder, serving bool) *Learner {
+	return &Learner{
+		holder:  h,
+		params:  ftrl{alpha: 0.1, beta: 1, l1: 0.5, l2: 2},
+		serving: serving,
+		state:   make(map[coord]*ftrlState),
+	}
+}
+
+func TestFTRLState(t *testing.T) {
+	p := ftrl{alpha: 0.1, beta: 1, l1: 0.5, l2: 2}
+	for _, w := range []float64{-0.7, 0, 1.3} {
+		for _, n := range []float64{0, 4, 99} {
+			s := p.state(w, n)
+			if got := p.weight(s); math.Abs(got-w) > 1e-12 || s.n != n {
+				t.Errorf("w %v, n %v: restored %v, state %v", w, n, got, s)
+			}
+		}
+	}
+}
+
+func TestFeedback(t *testing.T) {
+	h := testHolder(t, "default", countedModel)
+	l := testLearner(h, true)
+	p := l.params
+	req := &pb.Request{Geo: "de", Browser: 8, Features: map[string]string{"bid_floor": "2"}}
+
+	before, err := h.Current().score(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proba, updates, err := l.Feedback(req, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(proba-Sigmoid(before)) > 1e-12 || updates != 1 {
+		t.Errorf("proba %v != %v, updates %d", proba, Sigmoid(before), updates)
+	}
+
+	// serving model is replaced when updates are published
+	if h.Current().bias != -1 {
+		t.Errorf("model is updated before publish")
+	}
+	m := l.publish()
+	if h.Current() != m {
+		t.Errorf("published model doesn't serve requests")
+	}
+
+	expected := func(w, n, x float64) (float64, float64) {
+		s := p.state(w, n)
+		g := (proba - 1) * x
+		sigma := (math.Sqrt(n+g*g) - math.Sqrt(n)) / p.alpha
+		s.z += g - sigma*w
+		s.n += g * g
+		return p.weight(s), s.n
+	}
+
+	geoFeature, _ := m.schema.resolve("geo")
+	browserFeature, _ := m.schema.resolve("browser")
+	geo := newVariable(geoFeature)
+	browser := newVariable(browserFeature)
+	if w, n := expected(-1, 3, 1); m.bias != w || *m.biasCount != n {
+		t.Errorf("bias %v, count %v, expected %v, %v", m.bias, *m.biasCount, w, n)
+	}
+	// unseen geo updates fit.other
+	if w, n := expected(-1, 1, 1); m.other[geo] != w || m.otherCounts[geo] != n {
+		t.Errorf("fit.other %v, count %v, expected %v, %v", m.other[geo], m.otherCounts[geo], w, n)
+	}
+	value, _ := browser.makeValue(req, m.schema, &m.values)
+	if w, n := expected(0.5, 0, 1); m.coef[browser][value] != w || m.counts[browser][value] != n {
+		t.Errorf("browser %v, count %v, expected %v, %v", m.coef[browser][value], m.counts[browser][value], w, n)
+	}
+	bidFloor, _ := m.schema.resolve("bid_floor")
+	if w, _ := expected(0.5, 0, 2); m.weights[bidFloor] != w {
+		t.Errorf("bid_floor %v, expected %v", m.weights[bidFloor], w)
+	}
+
+	// coefficients of base model aren't modified
+	base := l.base
+	if base.bias != -1 || base.coef[browser][value] != 0.5 || base.other[geo] != -1 {
+		t.Errorf("base model is modified")
+	}
+	us, _ := geo.makeValue(&pb.Request{Geo: "us"}, m.schema, &m.values)
+	if m.coef[geo][us] != 1 {
+		t.Errorf("coefficient which isn't used by request is updated")
+	}
+
+	// compiled scores match updated coefficients,
+	// interaction has no fit.other
+	after, _ := m.score(req)
+	sum := m.bias + m.other[geo] + m.coef[browser][value] + m.weights[bidFloor]*2
+	if math.Abs(after-sum) > 1e-12 {
+		t.Errorf("score %v != %v", after, sum)
+	}
+	if after <= before {
+		t.Errorf("click hasn't increased score: %v -> %v", before, after)
+	}
+}
+
+func TestFeedbackShadow(t *testing.T) {
+	h := testHolder(t, "default", countedModel)
+	original := h.Current()
+	l := testLearner(h, false)
+
+	dir, err := ioutil.TempDir("", "goinfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	l.snapshotPath = filepath.Join(dir, "snapshot.model")
+
+	req := &pb.Request{Geo: "us", Browser: 8}
+	for i := 0; i < 3; i++ {
+		if _, _, err := l.Feedback(req, i%2 == 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if h.Current() != original {
+		t.Errorf("shadow updates are applied to serving model")
+	}
+
+	snapshot, err := loadModel("snapshot", l.snapshotPath, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := l.published.score(req)
+	if got, _ := snapshot.score(req); got != expected {
+		t.Errorf("snapshot score %v != %v", got, expected)
+	}
+
+	// training continues from snapshot the same way
+	restored := testLearner(&ModelHolder{name: "snapshot"}, false)
+	restored.holder.model.Store(snapshot)
+	p1, _, _ := l.Feedback(req, true)
+	p2, _, _ := restored.Feedback(req, true)
+	if math.Abs(p1-p2) > 1e-12 {
+		t.Errorf("restored learner predicts %v != %v", p2, p1)
+	}
+}
+
+func TestSnapshotKeepsLaterFeedback(t *testing.T) {
+	h := testHolder(t, "default", countedModel)
+	l := testLearner(h, false)
+
+	dir, err := ioutil.TempDir("", "goinfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	l.snapshotPath = filepath.Join(dir, "snapshot.model")
+
+	req := &pb.Request{Geo: "us", Browser: 8}
+	l.Feedback(req, true)
+	if m, unsaved := l.commit(true); m == nil || !unsaved {
+		t.Fatalf("update isn't taken for snapshot")
+	}
+
+	// feedback which arrives while model is written
+	// isn't in it, so it's saved by the next snapshot
+	l.Feedback(req, false)
+	if err := l.snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := loadModel("snapshot", l.snapshotPath, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := l.published.score(req)
+	if got, _ := snapshot.score(req); math.Abs(got-expected) > 1e-12 {
+		t.Errorf("snapshot score %v != %v", got, expected)
+	}
+}
+
+func TestRunWritesFinalSnapshot(t *testing.T) {
+	h := testHolder(t, "default", countedModel)
+	l := testLearner(h, false)
+	l.publishInterval = time.Hour
+	l.snapshotInterval = time.Hour
+
+	dir, err := ioutil.TempDir("", "goinfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	l.snapshotPath = filepath.Join(dir, "snapshot.model")
+
+	req := &pb.Request{Geo: "us", Browser: 8}
+	l.Feedback(req, true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		l.Run(ctx)
+	}()
+	cancel()
+	<-stopped
+
+	snapshot, err := loadModel("snapshot", l.snapshotPath, DefaultSchema())
+	if err != nil {
+		t.Fatalf("snapshot isn't written on shutdown: %v", err)
+	}
+	expected, _ := l.published.score(req)
+	if got, _ := snapshot.score(req); math.Abs(got-expected) > 1e-12 {
+		t.Errorf("snapshot score %v != %v", got, expected)
+	}
+}
+
+func TestFeedbackReload(t *testing.T) {
+	h := testHolder(t, "default", countedModel)
+	l := testLearner(h, true)
+	req := &pb.Request{Geo: "us"}
+
+	l.Feedback(req, true)
+	l.publish()
+
+	// model reloaded from file drops updates and counts them anew
+	fresh := testHolder(t, "default", countedModel).Current()
+	h.model.Store(fresh)
+	if _, updates, _ := l.Feedback(req, true); updates != 1 {
+		t.Errorf("updates %d != 1", updates)
+	}
+	if l.base != fresh || len(l.state) == 0 {
+		t.Errorf("learner isn't reset by reload")
+	}
+	if l.stateOf(coord{kind: coordBias}).n == 3 {
+		t.Errorf("bias isn't updated after reload")
+	}
+}
+
+func TestPublishDuringReload(t *testing.T) {
+	h := testHolder(t, "default", countedModel)
+	defer os.Remove(h.path)
+	l := testLearner(h, true)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		req := &pb.Request{Geo: "us"}
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				l.Feedback(req, true)
+				l.publish()
+			}
+		}
+	}()
+
+	const reloads = 100
+	for i := 1; i <= reloads; i++ {
+		writeModel(t, h.path, strings.Replace(countedModel, "0:bias:-1:3", "0:bias:"+strconv.Itoa(i), 1))
+		if err := h.Reload(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(done)
+	<-stopped
+
+	// the last model loaded from file is never replaced
+	// by one built from model loaded before it
+	l.publish()
+	if l.base.Bias() != reloads {
+		t.Errorf("learner is based on outdated model with bias %v", l.base.Bias())
+	}
+	if m := h.Current(); m != l.base && m != l.published {
+		t.Errorf("holder serves model which learner doesn't know")
+	}
+}
+
+func TestFeedbackRPC(t *testing.T) {
+	h := testHolder(t, "default", countedModel)
+	inf := testInferencer(h)
+	req := &pb.FeedbackRequest{Request: &pb.Request{Geo: "us"}, Click: true}
+
+	if _, err := inf.Feedback(context.Background(), req); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition without learner, got %v", err)
+	}
+	if _, err := inf.Feedback(context.Background(), &pb.FeedbackRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument without request, got %v", err)
+	}
+
+	inf.registry.learners = map[string]*Learner{"default": testLearner(h, true)}
+	resp, err := inf.Feedback(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ModelName != "default" || resp.Updates != 1 {
+		t.Errorf("unexpected response %v", resp)
+	}
+
+	classes := testHolder(t, "default", classesModel)
+	inf = testInferencer(classes)
+	inf.registry.learners = map[string]*Learner{"default": testLearner(classes, true)}
+	if _, err := inf.Feedback(context.Background(), req); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition for multi-class model, got %v", err)
+	}
+}
+
+func TestLearnersFromConfig(t *testing.T) {
+	holders := map[string]*ModelHolder{"default": {name: "default"}}
+	parse := func(data string) (map[string]*Learner, error) {
+		config := make(Yaml)
+		if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+			t.Fatal(err)
+		}
+		return learnersFromConfig(config, holders)
+	}
+
+	learners, err := parse(`
+online:
+ - model: default
+   alpha: 0.1
+   beta: 1
+   l1: 0
+   l2: 2
+   apply: serving
+   snapshot: /tmp/snapshot.model
+   snapshot_interval: 1m
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := learners["default"]
+	if l == nil || l.params != (ftrl{alpha: 0.1, beta: 1, l1: 0, l2: 2}) || !l.serving ||
+		l.snapshotPath != "/tmp/snapshot.model" || l.snapshotInterval.Minutes() != 1 ||
+		l.publishInterval != defaultPublishInterval {
+		t.Errorf("unexpected learner %+v", l)
+	}
+
+	errors := []struct {
+		section string
+		reason  string
+	}{
+		{"- model: other", `unknown model "other"`},
+		{"- {model: default, alpha: 0.1, beta: 1, l1: 0}", "l2 must be non-negative number"},
+		{"- {model: default, alpha: 0, beta: 1, l1: 0, l2: 0}", "alpha must be positive"},
+		{"- {model: default, alpha: 1, beta: 1, l1: 0, l2: 0, apply: all}", `apply must be serving or shadow, got "all"`},
+		{"- {model: default, alpha: 1, beta: 1, l1: 0, l2: 0, publish_interval: 1}", "bad publish_interval 1"},
+		{"- {model: default, alpha: 1, beta: 1, l1: 0, l2: 0}\n- {model: default, alpha: 1, beta: 1, l1: 0, l2: 0}",
+			`online[1]: duplicate model "default"`},
+	}
+	for _, e := range errors {
+		_, err := parse("online:\n" + e.section)
+		if err == nil || !strings.Contains(err.Error(), e.reason) {
+			t.Errorf("%q: expected %q, got %v", e.section, e.reason, err)
+		}
+	}
+}
+
+func TestPublishPatchesPreviousModel(t *testing.T) {
+	h := testHolder(t, "default", countedModel)
+	l := testLearner(h, false)
+
+	reqs := []*pb.Request{{Geo: "us", Browser: 8}, {Geo: "de"}, {Geo: "us", Browser: 3}}
+	for i, req := range reqs {
+		l.Feedback(req, i%2 == 0)
+		l.publish()
+	}
+
+	// model patched by each publish equals the one built
+	// from base with all updates at once
+	all := make(map[coord]ftrlState)
+	for c, s := range l.state {
+		all[c] = *s
+	}
+	expected := l.build(l.base, all)
+	for _, req := range reqs {
+		got, _ := l.published.score(req)
+		if want, _ := expected.score(req); math.Abs(got-want) > 1e-12 {
+			t.Errorf("%v: score %v != %v", req, got, want)
+		}
+	}
+	if l.published.Bias() != expected.Bias() || len(l.changed) != 0 {
+		t.Errorf("bias %v != %v", l.published.Bias(), expected.Bias())
+	}
+}
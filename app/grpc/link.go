@@ -0,0 +1,55 @@
+package serving
+
+import "math"
+
+// Link functions map linear score of model to its prediction,
+// they are declared in model header by "#link=<name>"
+const (
+	linkLogistic = "logistic"
+	linkIdentity = "identity"
+	linkLog      = "log"
+	linkProbit   = "probit"
+
+	defaultLink = linkLogistic
+)
+
+// linkFuncs are inverse link functions by name
+var linkFuncs = map[string]func(float64) float64{
+	linkLogistic: Sigmoid,
+	linkIdentity: func(x float64) float64 { return x },
+	linkLog:      math.Exp,
+	linkProbit:   normalCDF,
+}
+
+// probabilityLinks predict probability, only they are served
+// by PredictProba
+var probabilityLinks = map[string]bool{
+	linkLogistic: true,
+	linkProbit:   true,
+}
+
+// normalCDF is cumulative distribution function of standard normal
+func normalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// linkFromHeader reads link function of model, logistic by default
+func linkFromHeader(header *modelHeader) (string, *ModelError) {
+	link, ok := header.values["link"]
+	if !ok {
+		return defaultLink, nil
+	}
+	if _, ok := linkFuncs[link]; !ok {
+		return defaultLink, header.fail("link", "unknown link function %q", link)
+	}
+	return link, nil
+}
+
+// output transforms score of model by its link function.
+// Calibration of holder is applied to logistic models only
+func (h *ModelHolder) output(m *Model, score float64) float64 {
+	if m.link == linkLogistic {
+		return h.Calibration().Proba(score)
+	}
+	return linkFuncs[m.link](score)
+}
@@ -0,0 +1,92 @@
+package serving
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPredictLinks(t *testing.T) {
+	req := &pb.Request{Geo: "us"}
+	score := -0.5 + 1.25
+
+	cases := []struct {
+		header     string
+		link       string
+		prediction float64
+		proba      bool
+	}{
+		{"", linkLogistic, Sigmoid(score), true},
+		{"#link=logistic\n", linkLogistic, Sigmoid(score), true},
+		{"#link=identity\n", linkIdentity, score, false},
+		{"#link=log\n", linkLog, math.Exp(score), false},
+		{"#link=probit\n", linkProbit, 0.7733726476231317, true},
+	}
+
+	for _, c := range cases {
+		inf := testInferencer(testHolder(t, "default", c.header+"0:bias:-0.5\n1:geo=us:1.25\n"))
+
+		resp, err := inf.Predict(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Link != c.link || resp.Score != score ||
+			math.Abs(resp.Prediction-c.prediction) > 1e-12 {
+			t.Errorf("%q: unexpected prediction %v", c.header, resp)
+		}
+
+		proba, err := inf.PredictProba(context.Background(), req)
+		if c.proba {
+			if err != nil || proba.Proba != resp.Prediction {
+				t.Errorf("%q: proba %v != %v (%v)", c.header, proba.Proba, resp.Prediction, err)
+			}
+		} else if status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("%q: expected FailedPrecondition, got %v", c.header, err)
+		}
+
+		// raw probability is reported only if prediction is probability
+		explained, err := inf.Explain(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw := 0.0
+		if c.proba {
+			raw = c.prediction
+		}
+		if explained.Proba != resp.Prediction || math.Abs(explained.RawProba-raw) > 1e-12 {
+			t.Errorf("%q: explain proba %v, raw %v", c.header, explained.Proba, explained.RawProba)
+		}
+	}
+}
+
+func TestLinkErrors(t *testing.T) {
+	_, err := parse(&[]string{"#link=softplus", "0:geo=us:1"}, DefaultSchema())
+	errs, ok := err.(ModelErrors)
+	if !ok || len(errs) != 1 || errs[0].Line != 1 ||
+		errs[0].Reason != `unknown link function "softplus"` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestBinaryLink(t *testing.T) {
+	m, err := parse(&[]string{"#link=log", "0:bias:1", "1:geo=us:1"}, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteBinary(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := parseBinary(buf.Bytes(), DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.link != linkLog {
+		t.Errorf("link %q != %q", decoded.link, linkLog)
+	}
+}
@@ -0,0 +1,362 @@
+package serving
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pb "github.com/go-code/goinfer/api"
+	"github.com/go-code/goinfer/app/metrics"
+)
+
+// Model is a consistent snapshot of trained model:
+// variables, enumerated values and coefficients
+// loaded together from the same file.
+//
+// other keeps "fit.other" coefficients of variables,
+// they are used for values unseen in training
+//
+// bias is the intercept, it's added to every score,
+// hasBias tells whether model file has bias line
+//
+// weights are multiplied by values of numeric features
+//
+// hashing keeps weights of hashed variables if model is hashed
+//
+// link is the name of function which transforms score
+// to prediction (see linkFuncs), logistic by default
+//
+// classes keep coefficients of all classes of multi-class model
+//
+// splitOther are interactions which have "fit.other" written
+// for each feature in model file, e.g. "fit.otherX~Xfit.other".
+// It doesn't change scoring, model is exported in the same form
+//
+// counts are goFTRL "n" accumulators of coefficients, they
+// are optional and used for confidence only (see confidence)
+//
+// compiled is dense copy of coefficients used for scoring,
+// the maps are kept for introspection of model
+//
+// Model is never modified after loading, reload
+// produces a new instance which replaces the old one
+type Model struct {
+	schema    *Schema
+	variables VariableSet
+	values    KVstore
+	coef      CoeffStore
+	other     OtherStore
+	bias      float64
+	hasBias   bool
+	weights   map[FeatureName]float64
+	hashing   *Hashing
+	link      string
+	classes   *Classes
+	compiled  *compiled
+
+	splitOther VariableSet
+
+	counts      CoeffStore
+	otherCounts OtherStore
+	biasCount   *float64
+
+	// labels are variable names for metrics
+	labels map[Variable]string
+
+	name     string
+	path     string
+	checksum string
+	format   string
+	loadedAt time.Time
+}
+
+// formats of model file
+const (
+	formatText   = "text"
+	formatBinary = "binary"
+)
+
+func newModel(schema *Schema, variables VariableSet, values KVstore,
+	coef CoeffStore, other OtherStore) *Model {
+
+	labels := make(map[Variable]string, len(variables))
+	for v := range variables {
+		labels[v] = v.fileName(schema)
+	}
+
+	return &Model{
+		schema:    schema,
+		variables: variables,
+		values:    values,
+		coef:      coef,
+		other:     other,
+		link:      defaultLink,
+		labels:    labels,
+	}
+}
+
+// loadModel reads and parses model file, features of
+// model are base schema extended with ones from the file.
+// Binary models are detected by magic. File is read at once
+// rather than memory-mapped, since it can be rewritten in place
+// while loading and reading truncated mapping crashes the process
+func loadModel(name, path string, base *Schema) (*Model, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	sum := sha256.Sum256(data)
+
+	var m *Model
+	format := formatBinary
+	if isBinaryModel(data) {
+		m, err = parseBinary(data, base.clone())
+	} else {
+		format = formatText
+		var lines *[]string
+		if lines, err = scanlines(bytes.NewReader(data)); err != nil {
+			err = fmt.Errorf("failed to read file: %v", err)
+		} else {
+			m, err = parse(lines, base.clone())
+		}
+	}
+	if errs, ok := err.(ModelErrors); ok {
+		for _, e := range errs {
+			e.File = path
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.name = name
+	m.path = path
+	m.checksum = hex.EncodeToString(sum[:])
+	m.format = format
+	m.loadedAt = time.Now()
+	return m, nil
+}
+
+// Bias returns intercept of model
+func (m *Model) Bias() float64 {
+	return m.bias
+}
+
+// Variables returns number of model variables
+func (m *Model) Variables() int {
+	if m.hashing != nil {
+		return len(m.variables) + len(m.hashing.variables)
+	}
+	return len(m.variables)
+}
+
+// score sums coefficients of model factors for request.
+//
+// Values unseen in training get "fit.other" coefficient
+// of variable if model has it and zero otherwise.
+// Scoring doesn't allocate (see compiled)
+func (m *Model) score(req *pb.Request) (float64, error) {
+	score, _, err := m.scoreStats(req)
+	return score, err
+}
+
+// scoreStats computes score along with statistics
+// which are used for confidence
+func (m *Model) scoreStats(req *pb.Request) (float64, scoreStats, error) {
+	var stats scoreStats
+	score := m.bias + m.compiled.score(req, m, &stats)
+	if m.biasCount != nil {
+		stats.variance += countVariance(*m.biasCount)
+	}
+
+	stats.total += len(m.weights)
+	for f, w := range m.weights {
+		x, ok := m.schema.number(f, req)
+		if !ok {
+			// missing numeric value contributes nothing
+			continue
+		}
+		stats.found++
+		score += w * x
+	}
+
+	if m.hashing != nil {
+		score += m.hashing.score(req, m.schema, &stats)
+	}
+	return score, stats, nil
+}
+
+// hasCounts tells if model file has counts of coefficients
+func (m *Model) hasCounts() bool {
+	return len(m.counts) > 0 || len(m.otherCounts) > 0 || m.biasCount != nil
+}
+
+// variance of coefficient, coefficients without
+// counts are considered to be exact
+func (m *Model) variance(v Variable, value Value) float64 {
+	if n, ok := m.counts[v][value]; ok {
+		return countVariance(n)
+	}
+	return 0
+}
+
+// otherVariance is variance of unseen value of variable,
+// it's the prior one if model has no "fit.other" for it
+func (m *Model) otherVariance(v Variable) float64 {
+	if n, ok := m.otherCounts[v]; ok {
+		return countVariance(n)
+	}
+	if _, ok := m.other[v]; ok {
+		return 0
+	}
+	return priorVariance
+}
+
+// fileStamp identifies version of file on disk
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func stat(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// ModelHolder is a named container of model loaded from file.
+//
+// Model is stored behind atomic pointer, so it can be replaced
+// on the fly without interrupting requests which are in progress
+type ModelHolder struct {
+	name   string
+	path   string
+	schema *Schema
+	model  atomic.Value // *Model
+
+	// calibration is declared in config, it's kept across reloads
+	calibration Calibration
+
+	reloadMu sync.Mutex
+	stamp    fileStamp
+}
+
+// NewModelHolder loads model from path, schema is
+// the base schema declared in config
+func NewModelHolder(name, path string, schema *Schema) (*ModelHolder, error) {
+	h := &ModelHolder{name: name, path: path, schema: schema}
+	if err := h.Reload(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Current returns model which serves requests at the moment
+func (h *ModelHolder) Current() *Model {
+	return h.model.Load().(*Model)
+}
+
+// Calibration returns calibration of model
+func (h *ModelHolder) Calibration() Calibration {
+	if h.calibration == nil {
+		return noCalibration{}
+	}
+	return h.calibration
+}
+
+// checkCalibration rejects model which calibration of holder
+// can't be applied to, only logistic models are calibrated
+func (h *ModelHolder) checkCalibration(m *Model) error {
+	c := h.Calibration()
+	if _, ok := c.(noCalibration); ok || m.link == linkLogistic {
+		return nil
+	}
+	return fmt.Errorf("calibration %s applies to logistic models only, model has %s link",
+		c.Info().Type, m.link)
+}
+
+// Reload loads model from file and replaces the current one.
+// If loading fails, the current model keeps serving requests
+func (h *ModelHolder) Reload() error {
+	h.reloadMu.Lock()
+	defer h.reloadMu.Unlock()
+
+	// file version is remembered even if loading fails,
+	// so watcher doesn't retry until the file changes again
+	stamp, err := stat(h.path)
+	if err == nil {
+		h.stamp = stamp
+		err = h.swap()
+	}
+	if err != nil {
+		metrics.ModelReload(h.name, "failed")
+		log.Printf("Failed to reload model %s from %s: %v", h.name, h.path, err)
+		return err
+	}
+	return nil
+}
+
+func (h *ModelHolder) swap() error {
+	m, err := loadModel(h.name, h.path, h.schema)
+	if err != nil {
+		return err
+	}
+	if err := h.checkCalibration(m); err != nil {
+		return err
+	}
+	h.model.Store(m)
+	metrics.ModelReload(h.name, "ok")
+	metrics.ModelLoaded(h.name, float64(m.loadedAt.Unix()))
+
+	log.Printf("Model %s have loaded successfully from %s! Bias: %v",
+		h.name, h.path, m.bias)
+	for k, v := range m.coef {
+		log.Println(k.Name(m.schema), len(v))
+	}
+	return nil
+}
+
+// Watch polls model file every interval and reloads model
+// when file changes. It blocks until context is canceled
+func (h *ModelHolder) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var pending fileStamp
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.poll(&pending)
+		}
+	}
+}
+
+// poll reloads model once changed file stays the same for two
+// polls, so file which is still being written isn't loaded.
+// pending is the file version seen by previous poll
+func (h *ModelHolder) poll(pending *fileStamp) {
+	stamp, err := stat(h.path)
+	if err != nil {
+		log.Printf("Failed to stat model %s: %v", h.path, err)
+		return
+	}
+	h.reloadMu.Lock()
+	changed := stamp != h.stamp
+	h.reloadMu.Unlock()
+	if changed && stamp == *pending {
+		h.Reload()
+	}
+	*pending = stamp
+}
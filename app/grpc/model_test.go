@@ -0,0 +1,261 @@
+package serving
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+)
+
+func writeModel(t *testing.T, path, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testHolder loads model with given content from temporary file
+func testHolder(t *testing.T, name, content string) *ModelHolder {
+	t.Helper()
+	file, err := ioutil.TempFile("", "goinfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	writeModel(t, file.Name(), content)
+	h, err := NewModelHolder(name, file.Name(), DefaultSchema())
+	if err != nil {
+		t.Fatalf("failed to load model %s: %v", name, err)
+	}
+	return h
+}
+
+func TestReloadKeepsModelOnFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goinfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "trained.model")
+	writeModel(t, path, "0:geo=us:1.5\n1:geo=gb:-1.5\n")
+
+	h, err := NewModelHolder(DefaultModelName, path, DefaultSchema())
+	if err != nil {
+		t.Fatalf("initial load failed: %v", err)
+	}
+	inf := testInferencer(h)
+
+	req := &pb.Request{Geo: "us"}
+	before, _ := inf.PredictProba(context.Background(), req)
+
+	writeModel(t, path, "0:geo=us:broken\n")
+	if err := h.Reload(); err == nil {
+		t.Fatalf("expected reload error for broken model")
+	}
+
+	after, _ := inf.PredictProba(context.Background(), req)
+	if before.Proba != after.Proba {
+		t.Errorf("old model must keep serving: %v != %v", before.Proba, after.Proba)
+	}
+
+	writeModel(t, path, "0:geo=us:-1.5\n")
+	if err := h.Reload(); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+
+	swapped, _ := inf.PredictProba(context.Background(), req)
+	if swapped.Proba >= before.Proba {
+		t.Errorf("new model is not serving: %v >= %v", swapped.Proba, before.Proba)
+	}
+}
+
+func TestReloadRejectsEmptyModel(t *testing.T) {
+	h := testHolder(t, "default", "0:bias:-1\n1:geo=us:1.5\n")
+	defer os.Remove(h.path)
+
+	for _, content := range []string{"", "\n\n", "#link=identity\n", "0:buckets=hour:6,12\n"} {
+		writeModel(t, h.path, content)
+		err := h.Reload()
+		if errs, ok := err.(ModelErrors); !ok || !strings.Contains(errs.Error(), "no coefficients") {
+			t.Errorf("%q: expected no coefficients error, got %v", content, err)
+		}
+		if h.Current().Bias() != -1 {
+			t.Errorf("%q: old model must keep serving", content)
+		}
+	}
+}
+
+func TestWatchWaitsForCompleteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goinfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "trained.model")
+	writeModel(t, path, "0:bias:-1\n")
+	h, err := NewModelHolder(DefaultModelName, path, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pending fileStamp
+	// the file is cut off by trainer which is still writing it
+	writeModel(t, path, "0:bias:0.12\n1:geo=us:1\n2:geo=gb:0.")
+	h.poll(&pending)
+	writeModel(t, path, "0:bias:0.12\n1:geo=us:1\n2:geo=gb:0.5\n")
+	h.poll(&pending)
+	if h.Current().Bias() != -1 {
+		t.Fatalf("model is reloaded while file changes")
+	}
+
+	h.poll(&pending)
+	if m := h.Current(); m.Bias() != 0.12 || len(m.coef) != 1 || len(vocabularies(m)["geo"]) != 2 {
+		t.Errorf("complete model isn't loaded: bias %v", m.Bias())
+	}
+
+	// unchanged file isn't reloaded again
+	loaded := h.Current()
+	h.poll(&pending)
+	if h.Current() != loaded {
+		t.Errorf("unchanged model is reloaded")
+	}
+}
+
+func TestUnseenValueFallback(t *testing.T) {
+	h := testHolder(t, "default", strings.Join([]string{
+		"0:geo=us:1",
+		"1:geo=gb:2",
+		"2:geo=fit.other:-3",
+		"3:browser=8:0.5",
+		"4:geoXXbrowser=usX~X8:0.25",
+		"5:geoXXbrowser=fit.otherX~Xfit.other:-0.125",
+		"6:geo=fit.others:0.75",
+	}, "\n"))
+	m := h.Current()
+
+	cases := []struct {
+		req   *pb.Request
+		score float64
+	}{
+		// all values are seen
+		{&pb.Request{Geo: "us", Browser: 8}, 1 + 0.5 + 0.25},
+		// unseen geo falls back to fit.other of geo and interaction
+		{&pb.Request{Geo: "de", Browser: 8}, -3 + 0.5 - 0.125},
+		// unseen browser has no fit.other, so it contributes zero
+		{&pb.Request{Geo: "gb", Browser: 9}, 2 - 0.125},
+		// interaction of seen values can still be unseen
+		{&pb.Request{Geo: "gb", Browser: 8}, 2 + 0.5 - 0.125},
+		// value which only contains "fit.other" is a regular one
+		{&pb.Request{Geo: "fit.others"}, 0.75 - 0.125},
+	}
+
+	for _, c := range cases {
+		score, err := m.score(c.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if score != c.score {
+			t.Errorf("%v: score %v != %v", c.req, score, c.score)
+		}
+	}
+}
+
+func TestBias(t *testing.T) {
+	h := testHolder(t, "default", strings.Join([]string{
+		"0:bias:-2.5",
+		"1:geo=us:1",
+	}, "\n"))
+	m := h.Current()
+	if m.Bias() != -2.5 {
+		t.Fatalf("bias %v != -2.5", m.Bias())
+	}
+
+	cases := []struct {
+		req   *pb.Request
+		score float64
+	}{
+		{&pb.Request{Geo: "us"}, -2.5 + 1},
+		// bias is added even if no value is known
+		{&pb.Request{Geo: "de"}, -2.5},
+	}
+	for _, c := range cases {
+		score, err := m.score(c.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if score != c.score {
+			t.Errorf("%v: score %v != %v", c.req, score, c.score)
+		}
+	}
+
+	_, err := parse(&[]string{"0:bias:1", "1:intercept:2"}, DefaultSchema())
+	if err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("expected duplicate bias error, got %v", err)
+	}
+}
+
+func TestMixedOrderInteractions(t *testing.T) {
+	h := testHolder(t, "default", strings.Join([]string{
+		"0:bias:-1",
+		"1:geo=us:0.5",
+		"2:browser=8:0.25",
+		"3:geoXXbrowser=usX~X8:0.125",
+		"4:geoXXbrowserXXos_version=usX~X8X~Xmac10.12:2",
+		"5:geoXXbrowserXXos_version=usX~X9X~Xmac10.12:-2",
+		"6:geoXXbrowserXXos_version=fit.other:-0.5",
+		"7:zone_idXXbanner_idXXgeoXXbrowser=1X~X2X~XusX~X8:4",
+	}, "\n"))
+	m := h.Current()
+
+	if len(m.variables) != 5 {
+		t.Fatalf("expected 5 variables, got %d", len(m.variables))
+	}
+
+	cases := []struct {
+		req   *pb.Request
+		score float64
+	}{
+		{
+			&pb.Request{Geo: "us", Browser: 8, OsVersion: "mac10.12"},
+			-1 + 0.5 + 0.25 + 0.125 + 2,
+		},
+		{
+			&pb.Request{Geo: "us", Browser: 9, OsVersion: "mac10.12"},
+			-1 + 0.5 - 2,
+		},
+		// unseen triple falls back to fit.other
+		{
+			&pb.Request{Geo: "us", Browser: 8, OsVersion: "win10"},
+			-1 + 0.5 + 0.25 + 0.125 - 0.5,
+		},
+		{
+			&pb.Request{ZoneId: 1, BannerId: 2, Geo: "us", Browser: 8, OsVersion: "mac10.12"},
+			-1 + 0.5 + 0.25 + 0.125 + 2 + 4,
+		},
+	}
+	for _, c := range cases {
+		score, err := m.score(c.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if score != c.score {
+			t.Errorf("%v: score %v != %v", c.req, score, c.score)
+		}
+	}
+
+	v := newVariable(2, 3, 4)
+	if name := v.Name(m.schema); name != "{geo, browser, os_version}" {
+		t.Errorf("unexpected name %s", name)
+	}
+	if name := v.fileName(m.schema); name != "geoXXbrowserXXos_version" {
+		t.Errorf("unexpected file name %s", name)
+	}
+}
@@ -0,0 +1,240 @@
+package serving
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// PruneOptions select coefficients of variables which are kept,
+// bias, numeric weights and "fit.other" are always kept
+type PruneOptions struct {
+	// Threshold drops coefficients with smaller magnitude
+	Threshold float64
+	// Top keeps at most Top coefficients with the largest
+	// magnitude of each variable, zero means no limit
+	Top int
+}
+
+// ModelSize is an estimate of memory taken by model
+type ModelSize struct {
+	Coefficients int
+	Values       int
+	Bytes        int64
+}
+
+// approximate sizes of model structures in bytes
+const (
+	sizeMapEntry  = 16 // bucket overhead per map entry
+	sizeValue     = 24 // Value key without tokens
+	sizeFloat     = 8
+	sizeString    = 16
+	sizeToken     = 4
+	sizeSparseKey = 8
+)
+
+// Prune drops coefficients of variables, values which are no longer
+// referenced are removed from vocabulary and variables which have
+// neither coefficients nor "fit.other" are removed. Dropped values
+// fall back to "fit.other" as unseen ones do. Magnitude of coefficient
+// of multi-class model is the largest one of its classes
+func Prune(m *Model, opts PruneOptions) (*Model, error) {
+	if m.hashing != nil {
+		return nil, fmt.Errorf("hashed model can't be pruned")
+	}
+	if opts.Threshold < 0 || opts.Top < 0 {
+		return nil, fmt.Errorf("threshold and top must be non-negative")
+	}
+
+	magnitude := func(v Variable, value Value) float64 {
+		if m.classes == nil {
+			return math.Abs(m.coef[v][value])
+		}
+		var max float64
+		for i := range m.classes.labels {
+			max = math.Max(max, math.Abs(m.classes.coef[i][v][value]))
+		}
+		return max
+	}
+
+	variables := make([]Variable, 0, len(m.variables))
+	for v := range m.variables {
+		variables = append(variables, v)
+	}
+	sort.Slice(variables, func(i, j int) bool {
+		return m.labels[variables[i]] < m.labels[variables[j]]
+	})
+
+	vocab := m.vocabulary()
+	values := NewKVStore()
+	kept := make(VariableSet)
+	coef, counts := make(CoeffStore), make(CoeffStore)
+	other, otherCounts := make(OtherStore), make(OtherStore)
+	var classes *Classes
+	if c := m.classes; c != nil {
+		classes = &Classes{
+			labels:  c.labels,
+			bias:    c.bias,
+			coef:    make([]CoeffStore, len(c.labels)),
+			other:   c.other,
+			weights: c.weights,
+		}
+		for i := range classes.coef {
+			classes.coef[i] = make(CoeffStore)
+		}
+	}
+
+	for _, v := range variables {
+		type entry struct {
+			key       string
+			value     Value
+			magnitude float64
+		}
+		entries := make([]entry, 0, len(m.coef[v]))
+		for value := range m.coef[v] {
+			mag := magnitude(v, value)
+			if mag < opts.Threshold {
+				continue
+			}
+			vals := make([]string, v.size)
+			for i := range vals {
+				vals[i] = vocab[v.feature(i)][value.token(i)]
+			}
+			entries = append(entries, entry{strings.Join(vals, FeatureValueSeparator), value, mag})
+		}
+		if opts.Top > 0 && len(entries) > opts.Top {
+			sort.Slice(entries, func(i, j int) bool {
+				if entries[i].magnitude != entries[j].magnitude {
+					return entries[i].magnitude > entries[j].magnitude
+				}
+				return entries[i].key < entries[j].key
+			})
+			entries = entries[:opts.Top]
+		}
+		// vocabulary is rebuilt in the same order for the same input
+		sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+		c, hasOther := m.other[v]
+		if len(entries) == 0 && !hasOther {
+			continue
+		}
+		kept[v] = true
+		if hasOther {
+			other[v] = c
+			if n, ok := m.otherCounts[v]; ok {
+				otherCounts[v] = n
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		coef[v] = make(ValueStore, len(entries))
+		for _, e := range entries {
+			tokens := make([]uint32, v.size)
+			for i := range tokens {
+				tokens[i], _ = values.Set(v.feature(i), vocab[v.feature(i)][e.value.token(i)])
+			}
+			value := newValue(tokens...)
+			coef[v][value] = m.coef[v][e.value]
+			if n, ok := m.counts[v][e.value]; ok {
+				if counts[v] == nil {
+					counts[v] = make(ValueStore)
+				}
+				counts[v][value] = n
+			}
+			if classes != nil {
+				coefs := make([]float64, len(classes.labels))
+				for i := range coefs {
+					coefs[i] = m.classes.coef[i][v][e.value]
+				}
+				classes.setCoef(v, value, coefs)
+			}
+		}
+	}
+
+	p := newModel(m.schema, kept, *values, coef, other)
+	p.bias = m.bias
+	p.hasBias = m.hasBias
+	p.weights = m.weights
+	p.link = m.link
+	p.classes = classes
+	p.counts = counts
+	p.otherCounts = otherCounts
+	p.biasCount = m.biasCount
+	p.splitOther = m.splitOther
+	p.compiled = compile(p)
+	p.name = m.name
+	p.format = m.format
+	return p, nil
+}
+
+// Size estimates memory taken by coefficients, counts
+// and vocabulary of model, including compiled layout
+func (m *Model) Size() ModelSize {
+	var s ModelSize
+	k := int64(1)
+	if m.classes != nil {
+		k = int64(len(m.classes.labels)) + 1
+	}
+
+	for v, values := range m.coef {
+		entry := int64(sizeMapEntry + sizeValue + sizeToken*int(v.size) + sizeFloat)
+		s.Coefficients += len(values)
+		s.Bytes += k * entry * int64(len(values))
+		s.Bytes += entry * int64(len(m.counts[v]))
+	}
+	for _, index := range m.values.store {
+		for val := range index {
+			s.Values++
+			s.Bytes += int64(sizeMapEntry + sizeString + len(val) + sizeToken)
+		}
+	}
+
+	if c := m.compiled; c != nil {
+		s.Bytes += int64(sizeFloat * (len(c.coefs) + len(c.variances)))
+		for _, v := range c.vars {
+			s.Bytes += int64(len(v.sparse) * (sizeMapEntry + sizeSparseKey + sizeFloat))
+		}
+	}
+	if h := m.hashing; h != nil {
+		s.Coefficients += len(h.weights)
+		s.Bytes += int64(sizeFloat * len(h.weights))
+	}
+	return s
+}
+
+// PruneModel loads model file, prunes it and writes result
+// in the format of input. Both models are returned
+func PruneModel(in, out string, schema *Schema, opts PruneOptions) (*Model, *Model, error) {
+	m, err := loadModel("", in, schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := Prune(m, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.Create(out)
+	if err != nil {
+		return nil, nil, err
+	}
+	w := bufio.NewWriter(file)
+	write := WriteText
+	if m.format == formatBinary {
+		write = WriteBinary
+	}
+	if err := write(w, p); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return m, p, file.Close()
+}
@@ -0,0 +1,167 @@
+package serving
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+)
+
+const pruneModel = `0:bias:-1:10
+1:geo=us:1:99
+2:geo=gb:0.01
+3:geo=de:-0.5
+4:geo=fit.other:-0.25:3
+5:browser=8:0.001
+6:geoXXbrowser=usX~X8:0.75
+7:geoXXbrowser=gbX~X9:0.02
+8:bid_floor:0.5`
+
+func TestPrune(t *testing.T) {
+	lines := strings.Split(pruneModel, "\n")
+	m, err := parse(&lines, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		opts     PruneOptions
+		expected string
+	}{
+		{PruneOptions{Threshold: 0.1}, `0:bias:-1:10
+1:bid_floor:0.5
+2:geo=de:-0.5
+3:geo=us:1:99
+4:geo=fit.other:-0.25:3
+5:geoXXbrowser=usX~X8:0.75
+`},
+		{PruneOptions{Top: 1}, `0:bias:-1:10
+1:bid_floor:0.5
+2:browser=8:0.001
+3:geo=us:1:99
+4:geo=fit.other:-0.25:3
+5:geoXXbrowser=usX~X8:0.75
+`},
+		// top is taken of coefficients above threshold
+		{PruneOptions{Threshold: 0.6, Top: 2}, `0:bias:-1:10
+1:bid_floor:0.5
+2:geo=us:1:99
+3:geo=fit.other:-0.25:3
+4:geoXXbrowser=usX~X8:0.75
+`},
+	}
+	for _, c := range cases {
+		p, err := Prune(m, c.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := WriteText(&buf, p); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != c.expected {
+			t.Errorf("%+v: unexpected model:\n%s", c.opts, buf.String())
+		}
+	}
+
+	p, _ := Prune(m, PruneOptions{Threshold: 0.1})
+	vocab := vocabularies(p)
+	if len(vocab["geo"]) != 2 || vocab["geo"]["gb"] || len(vocab["browser"]) != 1 || vocab["browser"]["9"] {
+		t.Errorf("orphaned values are kept: %v", vocab)
+	}
+	if p.Variables() != 2 {
+		t.Errorf("expected browser to be removed, got %d variables", p.Variables())
+	}
+
+	// pruned value falls back to "fit.other"
+	reqs := []*pb.Request{
+		{Geo: "us", Browser: 8, Features: map[string]string{"bid_floor": "2"}},
+		{Geo: "gb", Browser: 9},
+	}
+	for i, expected := range []float64{1.75, -1.25} {
+		score, err := p.score(reqs[i])
+		if err != nil || math.Abs(score-expected) > 1e-12 {
+			t.Errorf("%v: score %v != %v (%v)", reqs[i], score, expected, err)
+		}
+	}
+	if s := CompareScores(m, p, reqs); s.Failed != 0 || math.Abs(s.Max-0.28) > 1e-12 {
+		t.Errorf("unexpected score changes %+v", s)
+	}
+
+	s1, s2 := m.Size(), p.Size()
+	if s1.Coefficients != 6 || s2.Coefficients != 3 || s1.Values != 5 || s2.Values != 3 || s2.Bytes >= s1.Bytes {
+		t.Errorf("unexpected sizes %+v -> %+v", s1, s2)
+	}
+}
+
+func TestPruneClasses(t *testing.T) {
+	lines := strings.Split(classesModel, "\n")
+	m, err := parse(&lines, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// magnitude of geo=us is 1 in banner and native classes
+	p, err := Prune(m, PruneOptions{Threshold: 0.6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Variables() != 1 || p.link != linkSoftmax {
+		t.Fatalf("unexpected model %d variables, link %s", p.Variables(), p.link)
+	}
+	req := &pb.Request{Geo: "us", Browser: 8}
+	s1, s2 := m.classScores(req), p.classScores(req)
+	for i := range s1 {
+		if math.Abs(s1[i]-0.5-s2[i]) > 1e-12 {
+			t.Errorf("class scores %v, expected %v less by 0.5", s2, s1)
+		}
+	}
+
+	hashed := []string{"#hash=fnv1a32", "#hash_buckets=4", "#variables=geo", "0:h=1:0.5"}
+	h, err := parse(&hashed, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Prune(h, PruneOptions{Threshold: 1}); err == nil {
+		t.Error("hashed model is pruned")
+	}
+}
+
+func TestPruneModel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goinfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	text := filepath.Join(dir, "model.txt")
+	binary := filepath.Join(dir, "model.bin")
+	writeModel(t, text, pruneModel)
+	if _, err := ConvertModel(text, binary, DefaultSchema()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, in := range []string{text, binary} {
+		out := in + ".pruned"
+		before, after, err := PruneModel(in, out, DefaultSchema(), PruneOptions{Top: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if before.format != after.format {
+			t.Errorf("%s: format %s != %s", in, after.format, before.format)
+		}
+
+		m, err := ValidateModel(out, DefaultSchema())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.format != before.format || m.Size() != after.Size() {
+			t.Errorf("%s: written model differs: %s %+v", in, m.format, m.Size())
+		}
+	}
+}
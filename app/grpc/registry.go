@@ -0,0 +1,249 @@
+package serving
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	pb "github.com/go-code/goinfer/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultModelName is the name of model declared
+// with legacy single "model" config key
+const DefaultModelName = "default"
+
+// Registry keeps all models declared in config by name.
+//
+// Config format is
+//
+//	models:
+//	 - name: <model name>
+//	   path: <path to model file>
+//	   calibration: <optional, see Calibration>
+//	default_model: <model name>
+//
+// Single "model: <path>" key is supported as well,
+// such model is registered as DefaultModelName
+//
+// Registry also keeps experiments (see Experiment), request
+// can name either model or experiment. Default can be experiment too
+//
+// Models can be scored in shadow by candidate models (see Shadow)
+// and updated online by feedback of requests (see Learner)
+type Registry struct {
+	holders     map[string]*ModelHolder
+	experiments map[string]*Experiment
+	shadows     map[string]*Shadow
+	learners    map[string]*Learner
+	defaultName string
+}
+
+// NewRegistry loads all models declared in config
+func NewRegistry(config Yaml) (*Registry, error) {
+	paths, defaultName, err := modelsFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	calibrations, err := calibrationsFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := SchemaFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Registry{
+		holders:     make(map[string]*ModelHolder, len(paths)),
+		defaultName: defaultName,
+	}
+	for name, path := range paths {
+		h, err := NewModelHolder(name, path, schema)
+		if err != nil {
+			return nil, fmt.Errorf("model %s: %v", name, err)
+		}
+		h.calibration = calibrations[name]
+		if err := h.checkCalibration(h.Current()); err != nil {
+			return nil, fmt.Errorf("model %s: %v", name, err)
+		}
+		r.holders[name] = h
+	}
+
+	r.experiments, err = experimentsFromConfig(config, schema, r.holders)
+	if err != nil {
+		return nil, err
+	}
+
+	r.shadows, err = shadowsFromConfig(config, r.holders)
+	if err != nil {
+		return nil, err
+	}
+
+	r.learners, err = learnersFromConfig(config, r.holders)
+	if err != nil {
+		return nil, err
+	}
+
+	_, isModel := r.holders[defaultName]
+	_, isExperiment := r.experiments[defaultName]
+	if !isModel && !isExperiment {
+		return nil, fmt.Errorf("default_model %q is not declared", defaultName)
+	}
+	return r, nil
+}
+
+// Get returns model holder by name, empty name stands for default model.
+// Unknown names produce grpc NotFound error
+func (r *Registry) Get(name string) (*ModelHolder, error) {
+	if name == "" {
+		name = r.defaultName
+	}
+	h, ok := r.holders[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown model %q", name)
+	}
+	return h, nil
+}
+
+// Route chooses model for request by its model_name, which
+// can name either model or experiment. Variant is the name
+// of experiment arm, it is empty when no experiment is involved
+func (r *Registry) Route(req *pb.Request) (*ModelHolder, string, error) {
+	name := req.GetModelName()
+	if name == "" {
+		name = r.defaultName
+	}
+
+	if e, ok := r.experiments[name]; ok {
+		arm, err := e.Choose(req)
+		if err != nil {
+			return nil, "", err
+		}
+		return arm.model, arm.name, nil
+	}
+
+	h, err := r.Get(name)
+	return h, "", err
+}
+
+// Shadow returns shadow scorer of model, nil if there is no one
+func (r *Registry) Shadow(model string) *Shadow {
+	return r.shadows[model]
+}
+
+// Learner returns online learner of model, nil if there is no one
+func (r *Registry) Learner(model string) *Learner {
+	return r.learners[model]
+}
+
+// Run starts shadow scoring and online learning workers.
+// It blocks until context is canceled and learners are stopped
+func (r *Registry) Run(ctx context.Context) {
+	for _, s := range r.shadows {
+		go s.Run(ctx)
+	}
+	var wg sync.WaitGroup
+	for _, l := range r.learners {
+		wg.Add(1)
+		go func(l *Learner) {
+			defer wg.Done()
+			l.Run(ctx)
+		}(l)
+	}
+	<-ctx.Done()
+	wg.Wait()
+}
+
+// Watch starts watching all model files for changes.
+// It blocks until context is canceled
+func (r *Registry) Watch(ctx context.Context, interval time.Duration) {
+	for _, h := range r.holders {
+		go h.Watch(ctx, interval)
+	}
+	<-ctx.Done()
+}
+
+func modelsFromConfig(config Yaml) (map[string]string, string, error) {
+	paths := make(map[string]string)
+
+	if path, ok := config["model"].(string); ok {
+		paths[DefaultModelName] = path
+	}
+
+	models, _ := config["models"].([]interface{})
+	for i, item := range models {
+		entry, ok := asYaml(item)
+		if !ok {
+			return nil, "", fmt.Errorf("models[%d]: expected name and path", i)
+		}
+		name, _ := entry["name"].(string)
+		path, _ := entry["path"].(string)
+		if name == "" || path == "" {
+			return nil, "", fmt.Errorf("models[%d]: expected name and path", i)
+		}
+		if _, dup := paths[name]; dup {
+			return nil, "", fmt.Errorf("models[%d]: duplicate model %q", i, name)
+		}
+		paths[name] = path
+	}
+
+	if len(paths) == 0 {
+		return nil, "", fmt.Errorf("no models declared in config")
+	}
+
+	defaultName, _ := config["default_model"].(string)
+	if defaultName == "" {
+		if len(paths) > 1 {
+			return nil, "", fmt.Errorf("default_model is required for multiple models")
+		}
+		for name := range paths {
+			defaultName = name
+		}
+	}
+
+	return paths, defaultName, nil
+}
+
+// calibrationsFromConfig reads calibrations of models,
+// models without calibration get none
+func calibrationsFromConfig(config Yaml) (map[string]Calibration, error) {
+	calibrations := make(map[string]Calibration)
+
+	if _, ok := config["model"].(string); ok {
+		c, err := calibrationFromConfig(config["calibration"])
+		if err != nil {
+			return nil, fmt.Errorf("model %s: %v", DefaultModelName, err)
+		}
+		calibrations[DefaultModelName] = c
+	}
+
+	models, _ := config["models"].([]interface{})
+	for _, item := range models {
+		entry, _ := asYaml(item)
+		name, _ := entry["name"].(string)
+		c, err := calibrationFromConfig(entry["calibration"])
+		if err != nil {
+			return nil, fmt.Errorf("model %s: %v", name, err)
+		}
+		calibrations[name] = c
+	}
+	return calibrations, nil
+}
+
+// asYaml converts nested config section to Yaml. Nested maps
+// have the type of the map config was unmarshaled into
+func asYaml(v interface{}) (Yaml, bool) {
+	switch m := v.(type) {
+	case Yaml:
+		return m, true
+	case map[interface{}]interface{}:
+		return Yaml(m), true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,87 @@
+package serving
+
+import (
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v2"
+)
+
+func TestModelsFromConfig(t *testing.T) {
+	data := `
+model: legacy.model
+models:
+ - name: mobile
+   path: mobile.model
+default_model: mobile
+`
+	config := make(Yaml)
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, defaultName, err := modelsFromConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if defaultName != "mobile" {
+		t.Errorf("default model %q != mobile", defaultName)
+	}
+	if paths[DefaultModelName] != "legacy.model" || paths["mobile"] != "mobile.model" {
+		t.Errorf("unexpected models %v", paths)
+	}
+
+	r := &Registry{holders: map[string]*ModelHolder{}, defaultName: defaultName}
+	if _, err := r.Get("unknown"); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+}
+
+func TestExperimentRouting(t *testing.T) {
+	data := `
+experiments:
+ - name: ctr_ab
+   key: banner_id
+   arms:
+    - model: control
+      weight: 3
+    - model: candidate
+      weight: 1
+      name: treatment
+`
+	config := make(Yaml)
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	holders := map[string]*ModelHolder{
+		"control":   {name: "control"},
+		"candidate": {name: "candidate"},
+	}
+	experiments, err := experimentsFromConfig(config, DefaultSchema(), holders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Registry{holders: holders, experiments: experiments, defaultName: "ctr_ab"}
+
+	hits := map[string]int{}
+	for banner := uint64(0); banner < 10000; banner++ {
+		req := &pb.Request{BannerId: banner}
+		h, variant, err := r.Route(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, _, _ := r.Route(req)
+		if h != again {
+			t.Fatalf("assignment of banner %d is not sticky", banner)
+		}
+		hits[variant]++
+	}
+
+	share := float64(hits["treatment"]) / 10000
+	if share < 0.22 || share > 0.28 {
+		t.Errorf("treatment share %.3f is far from 0.25: %v", share, hits)
+	}
+}
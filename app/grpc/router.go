@@ -0,0 +1,141 @@
+package serving
+
+import (
+	"fmt"
+
+	pb "github.com/go-code/goinfer/api"
+)
+
+// Experiment splits traffic between models by weights.
+//
+// Assignment is sticky: requests with the same value of key
+// field always go to the same arm. Experiment name salts the hash,
+// so different experiments split traffic independently
+//
+// Config format is
+//
+//	experiments:
+//	 - name: <experiment name>
+//	   key: <request field, e.g. banner_id>
+//	   arms:
+//	    - model: <model name>
+//	      weight: <relative weight>
+//	      name: <variant name, model name if empty>
+type Experiment struct {
+	name   string
+	schema *Schema
+	key    FeatureName
+	arms   []Arm
+	total  float64
+}
+
+// Arm is the variant of experiment served by model
+type Arm struct {
+	name   string
+	model  *ModelHolder
+	weight float64
+}
+
+// Choose picks experiment arm for request
+func (e *Experiment) Choose(req *pb.Request) (*Arm, error) {
+	var buf [maxValueLen]byte
+	val, ok := e.schema.appendValue(buf[:0], e.key, req)
+	if !ok {
+		val = val[:0]
+	}
+
+	h := hashFuncs["fnv1a64"]
+	h.writeString(e.name)
+	h.write([]byte{0})
+	h.write(val)
+
+	// top 53 bits of hash give uniform point in [0, 1)
+	point := float64(h.sum>>11) / (1 << 53) * e.total
+	for i := range e.arms {
+		point -= e.arms[i].weight
+		if point < 0 {
+			return &e.arms[i], nil
+		}
+	}
+	return &e.arms[len(e.arms)-1], nil
+}
+
+func experimentsFromConfig(config Yaml, schema *Schema,
+	holders map[string]*ModelHolder) (map[string]*Experiment, error) {
+
+	experiments := make(map[string]*Experiment)
+
+	items, _ := config["experiments"].([]interface{})
+	for i, item := range items {
+		entry, ok := asYaml(item)
+		if !ok {
+			return nil, fmt.Errorf("experiments[%d]: expected name, key and arms", i)
+		}
+
+		name, _ := entry["name"].(string)
+		key, _ := entry["key"].(string)
+		arms, _ := entry["arms"].([]interface{})
+		if name == "" || key == "" || len(arms) == 0 {
+			return nil, fmt.Errorf("experiments[%d]: expected name, key and arms", i)
+		}
+		if _, dup := experiments[name]; dup {
+			return nil, fmt.Errorf("experiments[%d]: duplicate experiment %q", i, name)
+		}
+		if _, dup := holders[name]; dup {
+			return nil, fmt.Errorf("experiments[%d]: %q is already a model name", i, name)
+		}
+
+		ftype, ok := schema.Lookup(FeatureNameString(key))
+		if !ok {
+			return nil, fmt.Errorf("experiment %s: unknown key %q", name, key)
+		}
+
+		e := &Experiment{name: name, schema: schema, key: ftype}
+		for j, a := range arms {
+			arm, err := armFromConfig(a, holders)
+			if err != nil {
+				return nil, fmt.Errorf("experiment %s: arms[%d]: %v", name, j, err)
+			}
+			e.arms = append(e.arms, arm)
+			e.total += arm.weight
+		}
+		experiments[name] = e
+	}
+
+	return experiments, nil
+}
+
+func armFromConfig(item interface{}, holders map[string]*ModelHolder) (Arm, error) {
+	entry, ok := asYaml(item)
+	if !ok {
+		return Arm{}, fmt.Errorf("expected model and weight")
+	}
+
+	model, _ := entry["model"].(string)
+	h, ok := holders[model]
+	if !ok {
+		return Arm{}, fmt.Errorf("unknown model %q", model)
+	}
+
+	weight, ok := asFloat(entry["weight"])
+	if !ok || weight <= 0 {
+		return Arm{}, fmt.Errorf("weight must be positive number")
+	}
+
+	name, _ := entry["name"].(string)
+	if name == "" {
+		name = model
+	}
+	return Arm{name: name, model: h, weight: weight}, nil
+}
+
+func asFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), true
+	case float64:
+		return x, true
+	default:
+		return 0, false
+	}
+}
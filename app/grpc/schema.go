@@ -0,0 +1,341 @@
+package serving
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	pb "github.com/go-code/goinfer/api"
+)
+
+const (
+	kindCategorical = "categorical"
+	kindNumeric     = "numeric"
+)
+
+// FeatureSpec describes how feature value is fetched from request:
+// either from request field or from request features map by name.
+//
+// Numeric feature is either multiplied by weight or bucketized
+// by boundaries from model file, bucket index is used as its
+// categorical value then. Kind of features which are missing
+// in config is decided by model file
+type FeatureSpec struct {
+	name    FeatureNameString
+	field   string
+	kind    string
+	buckets []float64
+
+	get requestField
+}
+
+// Schema enumerates features known to model, FeatureName
+// is the index of feature in schema.
+//
+// Base schema is declared in config file, features which
+// appear only in model file are added to it while parsing
+// and read from request features map, so new features can
+// be rolled out without changing the code
+//
+// Strict schema rejects features which are not declared
+// in config, model file can't introduce new ones then
+//
+// Config format is
+//
+//	features:
+//	 - name: <feature name in model file>
+//	   field: <request field, features map is used if empty>
+//	   type: <categorical (default) or numeric>
+//	strict_features: <true to reject undeclared features>
+type Schema struct {
+	features []FeatureSpec
+	index    map[FeatureNameString]FeatureName
+	strict   bool
+}
+
+// requestField fetches either string or number field of request,
+// numbers aren't formatted until it's needed
+type requestField struct {
+	str    func(req *pb.Request) string
+	number func(req *pb.Request) uint64
+}
+
+// requestFields are fields of request which can back features
+var requestFields = map[string]requestField{
+	"banner_id":  {number: (*pb.Request).GetBannerId},
+	"zone_id":    {number: (*pb.Request).GetZoneId},
+	"geo":        {str: (*pb.Request).GetGeo},
+	"browser":    {number: (*pb.Request).GetBrowser},
+	"os_version": {str: (*pb.Request).GetOsVersion},
+	"platform":   {number: (*pb.Request).GetPlatform},
+}
+
+// DefaultSchema is used when config doesn't declare features
+func DefaultSchema() *Schema {
+	s := NewSchema()
+	for _, name := range []string{
+		"zone_id", "banner_id", "geo", "browser", "os_version",
+	} {
+		s.add(FeatureSpec{
+			name:  FeatureNameString(name),
+			field: name,
+			kind:  kindCategorical,
+		})
+	}
+	return s
+}
+
+// NewSchema produces empty schema
+func NewSchema() *Schema {
+	return &Schema{index: make(map[FeatureNameString]FeatureName)}
+}
+
+// SchemaFromConfig reads base schema from config
+func SchemaFromConfig(config Yaml) (*Schema, error) {
+	strict, _ := config["strict_features"].(bool)
+
+	items, ok := config["features"].([]interface{})
+	if !ok {
+		s := DefaultSchema()
+		s.strict = strict
+		return s, nil
+	}
+
+	s := NewSchema()
+	s.strict = strict
+	for i, item := range items {
+		entry, ok := asYaml(item)
+		if !ok {
+			return nil, fmt.Errorf("features[%d]: expected name", i)
+		}
+
+		name, _ := entry["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("features[%d]: expected name", i)
+		}
+		if name == BiasName || name == InterceptName || name == BucketsName {
+			return nil, fmt.Errorf("features[%d]: %q is reserved", i, name)
+		}
+		if _, dup := s.Lookup(FeatureNameString(name)); dup {
+			return nil, fmt.Errorf("features[%d]: duplicate feature %q", i, name)
+		}
+
+		field, _ := entry["field"].(string)
+		if _, ok := requestFields[field]; field != "" && !ok {
+			return nil, fmt.Errorf("feature %s: unknown request field %q", name, field)
+		}
+
+		kind := kindCategorical
+		if v, ok := entry["type"].(string); ok {
+			kind = v
+		}
+		if kind != kindCategorical && kind != kindNumeric {
+			return nil, fmt.Errorf("feature %s: unsupported type %q", name, kind)
+		}
+
+		_, err := s.add(FeatureSpec{
+			name:  FeatureNameString(name),
+			field: field,
+			kind:  kind,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Lookup finds feature by name
+func (s *Schema) Lookup(name FeatureNameString) (FeatureName, bool) {
+	f, ok := s.index[name]
+	return f, ok
+}
+
+// Name returns name of feature
+func (s *Schema) Name(f FeatureName) FeatureNameString {
+	if int(f) >= len(s.features) {
+		return ""
+	}
+	return s.features[f].name
+}
+
+// Len returns number of features in schema
+func (s *Schema) Len() int {
+	return len(s.features)
+}
+
+// resolve finds feature by name, unknown features
+// are added to schema and read from request features map.
+// Strict schema doesn't accept unknown features. Names must
+// be valid UTF-8, since they become labels of metrics
+func (s *Schema) resolve(name FeatureNameString) (FeatureName, error) {
+	if f, ok := s.index[name]; ok {
+		return f, nil
+	}
+	if !utf8.ValidString(string(name)) {
+		return 0, fmt.Errorf("feature name %q isn't valid UTF-8", name)
+	}
+	if s.strict || name == "" {
+		return 0, fmt.Errorf("unknown feature %q", name)
+	}
+	return s.add(FeatureSpec{name: name})
+}
+
+func (s *Schema) add(spec FeatureSpec) (FeatureName, error) {
+	if len(s.features) > math.MaxUint8 {
+		return 0, fmt.Errorf("too many features, can't add %q", spec.name)
+	}
+	f := FeatureName(len(s.features))
+	if spec.field != "" {
+		spec.get = requestFields[spec.field]
+	}
+	s.features = append(s.features, spec)
+	s.index[spec.name] = f
+	return f, nil
+}
+
+func (s *Schema) clone() *Schema {
+	c := &Schema{
+		features: make([]FeatureSpec, len(s.features)),
+		index:    make(map[FeatureNameString]FeatureName, len(s.index)),
+		strict:   s.strict,
+	}
+	copy(c.features, s.features)
+	for k, v := range s.index {
+		c.index[k] = v
+	}
+	return c
+}
+
+// setNumeric marks feature as numeric, categorical
+// features can't be used as numeric ones
+func (s *Schema) setNumeric(f FeatureName) error {
+	spec := &s.features[f]
+	if spec.kind == kindCategorical {
+		return fmt.Errorf("feature %s is categorical", spec.name)
+	}
+	spec.kind = kindNumeric
+	return nil
+}
+
+// setBuckets marks feature as numeric bucketized by boundaries,
+// boundaries must be strictly increasing
+func (s *Schema) setBuckets(f FeatureName, buckets []float64) error {
+	if err := s.setNumeric(f); err != nil {
+		return err
+	}
+	spec := &s.features[f]
+	if spec.buckets != nil {
+		return fmt.Errorf("feature %s already has buckets", spec.name)
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return fmt.Errorf("buckets of %s must be increasing", spec.name)
+		}
+	}
+	spec.buckets = buckets
+	return nil
+}
+
+// bucketized tells if values of feature are bucket indices
+func (s *Schema) bucketized(f FeatureName) bool {
+	return s.features[f].buckets != nil
+}
+
+// fromRequest fetches value of feature from request,
+// bucket index is returned for bucketized features
+func (s *Schema) fromRequest(f FeatureName, req *pb.Request) (string, error) {
+	if int(f) >= len(s.features) {
+		return "", fmt.Errorf("unknown request feature %v", f)
+	}
+	spec := s.features[f]
+	if spec.buckets == nil {
+		return s.raw(spec, req), nil
+	}
+
+	x, err := s.numeric(f, req)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(bucketOf(spec.buckets, x)), nil
+}
+
+// bucketOf finds index of bucket, it's the number
+// of boundaries not greater than x
+func bucketOf(buckets []float64, x float64) int {
+	return sort.Search(len(buckets), func(i int) bool {
+		return buckets[i] > x
+	})
+}
+
+// numeric fetches value of numeric feature from request.
+// MissingValueError is returned if value is missing or
+// isn't a number
+func (s *Schema) numeric(f FeatureName, req *pb.Request) (float64, error) {
+	if int(f) >= len(s.features) {
+		return 0, fmt.Errorf("unknown request feature %v", f)
+	}
+	x, ok := s.number(f, req)
+	if !ok {
+		return 0, &MissingValueError{Feature: f, Value: s.raw(s.features[f], req)}
+	}
+	return x, nil
+}
+
+// number fetches value of numeric feature without allocations,
+// false is returned if value is missing or isn't a number
+func (s *Schema) number(f FeatureName, req *pb.Request) (float64, bool) {
+	spec := &s.features[f]
+	if spec.get.number != nil {
+		return float64(spec.get.number(req)), true
+	}
+
+	var val string
+	if spec.get.str != nil {
+		val = spec.get.str(req)
+	} else {
+		val = req.GetFeatures()[string(spec.name)]
+	}
+	if val == "" {
+		return 0, false
+	}
+	x, err := strconv.ParseFloat(val, 64)
+	if err != nil || math.IsNaN(x) {
+		return 0, false
+	}
+	return x, true
+}
+
+// appendValue appends value of feature to dst as it's written
+// in model file. Unlike fromRequest it doesn't allocate if dst
+// has enough capacity, false is returned for missing values
+func (s *Schema) appendValue(dst []byte, f FeatureName, req *pb.Request) ([]byte, bool) {
+	spec := &s.features[f]
+	switch {
+	case spec.buckets != nil:
+		x, ok := s.number(f, req)
+		if !ok {
+			return dst, false
+		}
+		return strconv.AppendInt(dst, int64(bucketOf(spec.buckets, x)), 10), true
+	case spec.get.number != nil:
+		return strconv.AppendUint(dst, spec.get.number(req), 10), true
+	case spec.get.str != nil:
+		return append(dst, spec.get.str(req)...), true
+	default:
+		return append(dst, req.GetFeatures()[string(spec.name)]...), true
+	}
+}
+
+func (s *Schema) raw(spec FeatureSpec, req *pb.Request) string {
+	switch {
+	case spec.get.number != nil:
+		return strconv.FormatUint(spec.get.number(req), 10)
+	case spec.get.str != nil:
+		return spec.get.str(req)
+	default:
+		return req.GetFeatures()[string(spec.name)]
+	}
+}
@@ -0,0 +1,114 @@
+package serving
+
+import (
+	"strings"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+	"gopkg.in/yaml.v2"
+)
+
+func TestSchemaFromConfig(t *testing.T) {
+	data := `
+features:
+ - name: zone
+   field: zone_id
+ - name: segment
+`
+	config := make(Yaml)
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := SchemaFromConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &pb.Request{ZoneId: 42, Features: map[string]string{"segment": "sport"}}
+	for name, expected := range map[FeatureNameString]string{
+		"zone":    "42",
+		"segment": "sport",
+	} {
+		f, ok := schema.Lookup(name)
+		if !ok {
+			t.Fatalf("feature %s is not declared", name)
+		}
+		if val, _ := schema.fromRequest(f, req); val != expected {
+			t.Errorf("%s: %q != %q", name, val, expected)
+		}
+	}
+}
+
+func TestFeaturesFromModelFile(t *testing.T) {
+	h := testHolder(t, "default",
+		"0:geo=us:1\n1:segment=sport:2\n2:geoXXsegment=usX~Xsport:3\n")
+	m := h.Current()
+
+	if _, ok := m.schema.Lookup("segment"); !ok {
+		t.Fatalf("segment is not added to schema")
+	}
+	if _, ok := h.schema.Lookup("segment"); ok {
+		t.Errorf("base schema must not be modified")
+	}
+
+	req := &pb.Request{Geo: "us", Features: map[string]string{"segment": "sport"}}
+	if score, _ := m.score(req); score != 6 {
+		t.Errorf("score %v != 6", score)
+	}
+}
+
+func TestNumericFeatures(t *testing.T) {
+	h := testHolder(t, "default", strings.Join([]string{
+		"0:bid_floor:0.5",
+		"1:buckets=hour:6,12,18",
+		"2:hour=0:-1",
+		"3:hour=2:1",
+		"4:hour=fit.other:0.25",
+		"5:hourXXgeo=3X~Xus:2",
+	}, "\n"))
+	m := h.Current()
+
+	cases := []struct {
+		features map[string]string
+		score    float64
+	}{
+		{map[string]string{"bid_floor": "2", "hour": "3"}, 0.5*2 - 1},
+		// boundary belongs to the upper bucket
+		{map[string]string{"bid_floor": "0", "hour": "12"}, 1},
+		{map[string]string{"hour": "23"}, 0.25 + 2},
+		// missing and broken values fall back
+		{map[string]string{"bid_floor": "x", "hour": ""}, 0.25},
+	}
+	for _, c := range cases {
+		req := &pb.Request{Geo: "us", Features: c.features}
+		score, err := m.score(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if score != c.score {
+			t.Errorf("%v: score %v != %v", c.features, score, c.score)
+		}
+	}
+}
+
+func TestNumericFeatureFromConfig(t *testing.T) {
+	config := Yaml{"features": []interface{}{
+		Yaml{"name": "geo", "field": "geo"},
+		Yaml{"name": "age", "type": "numeric"},
+	}}
+	schema, err := SchemaFromConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parse(&[]string{"0:age=1:1"}, schema.clone()); err == nil {
+		t.Errorf("numeric feature without buckets must be rejected")
+	}
+	if _, err := parse(&[]string{"0:geo:1"}, schema.clone()); err == nil {
+		t.Errorf("categorical feature can't have weight")
+	}
+	if _, err := parse(&[]string{"0:age:1"}, schema.clone()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
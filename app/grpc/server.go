@@ -2,8 +2,10 @@ package serving
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net"
+	"time"
 
 	pb "github.com/go-code/goinfer/api"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
@@ -28,9 +30,23 @@ func Start(ctx context.Context, addr string, config Yaml) error {
 
 	server := grpc.NewServer(
 		grpc.UnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),
+		grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor),
 	)
 	myservice := NewInferencer(config)
 	pb.RegisterInferencerServer(server, myservice)
+	running := make(chan struct{})
+	go func() {
+		defer close(running)
+		myservice.Run(ctx)
+	}()
+
+	if interval, ok := config["reload_interval"].(string); ok {
+		d, err := time.ParseDuration(interval)
+		if err != nil {
+			return fmt.Errorf("bad reload_interval %q: %v", interval, err)
+		}
+		go myservice.Watch(ctx, d)
+	}
 
 	grpc_prometheus.EnableHandlingTimeHistogram(
 		grpc_prometheus.WithHistogramBuckets([]float64{
@@ -41,7 +57,12 @@ func Start(ctx context.Context, addr string, config Yaml) error {
 
 	select {
 	case <-ctx.Done():
+		// streams are never finished by clients,
+		// so they have to be closed before graceful stop
+		myservice.Shutdown()
 		server.GracefulStop()
+		// final snapshots of online learners are written
+		<-running
 		return ctx.Err()
 	case err := <-Errch(func() error { return server.Serve(*listener) }):
 		return err
@@ -0,0 +1,192 @@
+package serving
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	pb "github.com/go-code/goinfer/api"
+	"github.com/go-code/goinfer/app/metrics"
+)
+
+const (
+	defaultShadowQueue     = 1000
+	defaultShadowThreshold = 0.5
+)
+
+// Shadow scores requests of primary model by candidate model
+// in background. Candidate results are never returned to the
+// caller, they are only compared with primary ones.
+//
+// Differences are exported as metrics and optionally
+// written to JSONL file for offline analysis
+//
+// Config format is
+//
+//	shadows:
+//	 - model: <primary model name>
+//	   candidate: <candidate model name>
+//	   threshold: <probability threshold for sign disagreements>
+//	   queue: <max number of requests waiting for scoring>
+//	   log: <path to JSONL file, optional>
+type Shadow struct {
+	primary   *ModelHolder
+	candidate *ModelHolder
+	threshold float64
+	queue     chan shadowTask
+	logPath   string
+}
+
+type shadowTask struct {
+	req   *pb.Request
+	proba float64
+}
+
+// shadowRecord is the line of JSONL log
+type shadowRecord struct {
+	Time           time.Time   `json:"time"`
+	Model          string      `json:"model"`
+	Candidate      string      `json:"candidate"`
+	Request        *pb.Request `json:"request"`
+	Proba          float64     `json:"proba"`
+	CandidateProba float64     `json:"candidate_proba"`
+}
+
+// Submit queues request for candidate scoring. Request is
+// dropped if the queue is full, so serving is never slowed down.
+// Request must not be modified after submit
+func (s *Shadow) Submit(req *pb.Request, proba float64) {
+	select {
+	case s.queue <- shadowTask{req: req, proba: proba}:
+	default:
+		metrics.ShadowSkipped(s.primary.name, s.candidate.name, "queue_full")
+	}
+}
+
+// Run scores queued requests by candidate model.
+// It blocks until context is canceled
+func (s *Shadow) Run(ctx context.Context) {
+	var w *bufio.Writer
+	var enc *json.Encoder
+	if s.logPath != "" {
+		file, err := os.OpenFile(s.logPath,
+			os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Printf("Failed to open shadow log %s: %v", s.logPath, err)
+		} else {
+			w = bufio.NewWriter(file)
+			defer file.Close()
+			defer w.Flush()
+			enc = json.NewEncoder(w)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case task := <-s.queue:
+			s.compare(task, enc)
+			// log is flushed once queue is drained, so records
+			// are seen by readers soon and crash loses few of them
+			if w != nil && len(s.queue) == 0 {
+				if err := w.Flush(); err != nil {
+					log.Printf("Failed to write shadow log %s: %v", s.logPath, err)
+				}
+			}
+		}
+	}
+}
+
+func (s *Shadow) compare(task shadowTask, enc *json.Encoder) {
+	m := s.candidate.Current()
+	score, err := m.score(task.req)
+	if err == nil {
+		err = singleClass(s.candidate.name, m)
+	}
+	if err != nil {
+		metrics.ShadowSkipped(s.primary.name, s.candidate.name, "error")
+		return
+	}
+	proba := s.candidate.output(m, score)
+
+	metrics.ShadowDelta(s.primary.name, s.candidate.name,
+		math.Abs(proba-task.proba))
+	if (proba >= s.threshold) != (task.proba >= s.threshold) {
+		metrics.ShadowDisagreement(s.primary.name, s.candidate.name)
+	}
+
+	if enc == nil {
+		return
+	}
+	err = enc.Encode(shadowRecord{
+		Time:           time.Now(),
+		Model:          s.primary.name,
+		Candidate:      s.candidate.name,
+		Request:        task.req,
+		Proba:          task.proba,
+		CandidateProba: proba,
+	})
+	if err != nil {
+		log.Printf("Failed to write shadow log %s: %v", s.logPath, err)
+	}
+}
+
+func shadowsFromConfig(config Yaml,
+	holders map[string]*ModelHolder) (map[string]*Shadow, error) {
+
+	shadows := make(map[string]*Shadow)
+
+	items, _ := config["shadows"].([]interface{})
+	for i, item := range items {
+		entry, ok := asYaml(item)
+		if !ok {
+			return nil, fmt.Errorf("shadows[%d]: expected model and candidate", i)
+		}
+
+		model, _ := entry["model"].(string)
+		candidate, _ := entry["candidate"].(string)
+		primary, ok := holders[model]
+		if !ok {
+			return nil, fmt.Errorf("shadows[%d]: unknown model %q", i, model)
+		}
+		shadow, ok := holders[candidate]
+		if !ok {
+			return nil, fmt.Errorf("shadows[%d]: unknown candidate %q", i, candidate)
+		}
+		if _, dup := shadows[model]; dup {
+			return nil, fmt.Errorf("shadows[%d]: model %q already has candidate", i, model)
+		}
+
+		threshold := defaultShadowThreshold
+		if v, ok := entry["threshold"]; ok {
+			if threshold, ok = asFloat(v); !ok {
+				return nil, fmt.Errorf("shadows[%d]: threshold must be number", i)
+			}
+		}
+
+		queue := defaultShadowQueue
+		if v, ok := entry["queue"]; ok {
+			if queue, ok = v.(int); !ok || queue <= 0 {
+				return nil, fmt.Errorf("shadows[%d]: queue must be positive integer", i)
+			}
+		}
+
+		logPath, _ := entry["log"].(string)
+
+		shadows[model] = &Shadow{
+			primary:   primary,
+			candidate: shadow,
+			threshold: threshold,
+			queue:     make(chan shadowTask, queue),
+			logPath:   logPath,
+		}
+	}
+
+	return shadows, nil
+}
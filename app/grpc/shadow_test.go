@@ -0,0 +1,77 @@
+package serving
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/go-code/goinfer/api"
+)
+
+func TestShadowCompare(t *testing.T) {
+	primary := testHolder(t, "primary", "0:geo=us:1\n")
+	candidate := testHolder(t, "candidate", "0:geo=us:-1\n")
+
+	s := &Shadow{primary: primary, candidate: candidate, threshold: 0.5}
+
+	var buf bytes.Buffer
+	req := &pb.Request{Geo: "us"}
+	s.compare(shadowTask{req: req, proba: Sigmoid(1)}, json.NewEncoder(&buf))
+
+	var rec shadowRecord
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("bad shadow log %q: %v", buf.String(), err)
+	}
+	if rec.Model != "primary" || rec.Candidate != "candidate" {
+		t.Errorf("unexpected models in record %+v", rec)
+	}
+	if rec.Proba != Sigmoid(1) || rec.CandidateProba != Sigmoid(-1) {
+		t.Errorf("unexpected probabilities in record %+v", rec)
+	}
+	if rec.Request.GetGeo() != "us" {
+		t.Errorf("request is not logged %+v", rec)
+	}
+}
+
+func TestShadowLogFlush(t *testing.T) {
+	primary := testHolder(t, "primary", "0:geo=us:1\n")
+	candidate := testHolder(t, "candidate", "0:geo=us:-1\n")
+
+	dir, err := ioutil.TempDir("", "goinfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &Shadow{
+		primary:   primary,
+		candidate: candidate,
+		threshold: 0.5,
+		logPath:   filepath.Join(dir, "shadow.jsonl"),
+		queue:     make(chan shadowTask, 10),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	s.Submit(&pb.Request{Geo: "us"}, Sigmoid(1))
+	s.Submit(&pb.Request{Geo: "gb"}, Sigmoid(0))
+
+	// records are written while shadow is running
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := ioutil.ReadFile(s.logPath)
+		if bytes.Count(data, []byte("\n")) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("shadow log isn't flushed: %q", data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
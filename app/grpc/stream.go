@@ -0,0 +1,98 @@
+package serving
+
+import (
+	"io"
+
+	pb "github.com/go-code/goinfer/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultStreamWindow = 128
+
+// PredictProbaStream predicts probabilities for requests of
+// long-living stream. Each response carries id of its request.
+//
+// Requests are read and scored by separate goroutine, computed
+// responses wait for sending in buffer of streamWindow size.
+// When client doesn't read responses, buffer fills up and server
+// stops reading requests, so grpc flow control slows the client down.
+//
+// On server shutdown responses which are already computed are sent,
+// then stream is finished with Unavailable code, so client can
+// reconnect to another replica
+func (inf *Inferencer) PredictProbaStream(
+	stream pb.Inferencer_PredictProbaStreamServer) error {
+
+	ctx := stream.Context()
+	results := make(chan *pb.StreamResponse, inf.streamWindow)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(results)
+		for {
+			in, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					errc <- err
+				}
+				return
+			}
+
+			out := &pb.StreamResponse{Id: in.GetId()}
+			resp, err := inf.predict(in.GetRequest())
+			if err != nil {
+				st := status.Convert(err)
+				out.Code = int32(st.Code())
+				out.Error = st.Message()
+			} else {
+				out.Response = resp
+			}
+
+			select {
+			case results <- out:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case out, ok := <-results:
+			if !ok {
+				select {
+				case err := <-errc:
+					return err
+				default:
+					return nil
+				}
+			}
+			if err := stream.Send(out); err != nil {
+				return err
+			}
+		case <-inf.done:
+			return flush(stream, results)
+		}
+	}
+}
+
+// flush sends responses which are already computed
+// and finishes stream because of shutdown
+func flush(stream pb.Inferencer_PredictProbaStreamServer,
+	results <-chan *pb.StreamResponse) error {
+
+	for {
+		select {
+		case out, ok := <-results:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(out); err != nil {
+				return err
+			}
+		default:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package serving
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeStream replays requests and collects responses
+type fakeStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	requests  chan *pb.StreamRequest
+	responses []*pb.StreamResponse
+}
+
+func (s *fakeStream) Context() context.Context { return s.ctx }
+
+func (s *fakeStream) Send(m *pb.StreamResponse) error {
+	s.responses = append(s.responses, m)
+	return nil
+}
+
+func (s *fakeStream) Recv() (*pb.StreamRequest, error) {
+	select {
+	case req, ok := <-s.requests:
+		if !ok {
+			return nil, io.EOF
+		}
+		return req, nil
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+func TestPredictProbaStream(t *testing.T) {
+	inf := testInferencer(testHolder(t, "default", "0:geo=us:1\n"))
+
+	stream := &fakeStream{
+		ctx:      context.Background(),
+		requests: make(chan *pb.StreamRequest, 2),
+	}
+	stream.requests <- &pb.StreamRequest{Id: 7, Request: &pb.Request{Geo: "us"}}
+	stream.requests <- &pb.StreamRequest{Id: 8, Request: &pb.Request{ModelName: "unknown"}}
+	close(stream.requests)
+
+	if err := inf.PredictProbaStream(stream); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stream.responses) != 2 {
+		t.Fatalf("expected 2 responses, got %v", stream.responses)
+	}
+	first, second := stream.responses[0], stream.responses[1]
+	if first.GetId() != 7 || first.GetResponse().GetProba() != Sigmoid(1) {
+		t.Errorf("unexpected response %v", first)
+	}
+	if second.GetId() != 8 || codes.Code(second.GetCode()) != codes.NotFound {
+		t.Errorf("unexpected response %v", second)
+	}
+}
+
+func TestPredictProbaStreamShutdown(t *testing.T) {
+	inf := testInferencer(testHolder(t, "default", "0:geo=us:1\n"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &fakeStream{ctx: ctx, requests: make(chan *pb.StreamRequest)}
+
+	inf.Shutdown()
+	err := inf.PredictProbaStream(stream)
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable on shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package serving
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	pb "github.com/go-code/goinfer/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WriteText encodes model in text format parse reads. Output is
+// canonical: header goes first, then buckets, bias, numeric weights,
+// variables sorted by name with values sorted and "fit.other" last,
+// and hashed buckets. Bias and form of "fit.other" are kept as in
+// model file. Lines are numbered from 0, so model written
+// from parsed output of WriteText is written byte for byte the same
+func WriteText(w io.Writer, m *Model) error {
+	b := bufio.NewWriter(w)
+	no := 0
+	line := func(name, coef string, count *float64) {
+		fmt.Fprintf(b, "%d:%s:%s", no, name, coef)
+		if count != nil {
+			fmt.Fprintf(b, ":%s", formatFloat(*count))
+		}
+		b.WriteByte('\n')
+		no++
+	}
+
+	// coefs formats coefficient of all classes of multi-class model
+	coefs := func(c float64, class func(i int) float64) string {
+		if m.classes == nil {
+			return formatFloat(c)
+		}
+		items := make([]string, len(m.classes.labels))
+		for i := range items {
+			items[i] = formatFloat(class(i))
+		}
+		return strings.Join(items, ",")
+	}
+
+	if h := m.hashing; h != nil {
+		names := make([]string, len(h.variables))
+		for i, v := range h.variables {
+			names[i] = v.fileName(m.schema)
+		}
+		fmt.Fprintf(b, "#hash=%s\n#hash_buckets=%d\n#variables=%s\n",
+			h.name, len(h.weights), strings.Join(names, ","))
+	}
+	if m.classes != nil {
+		fmt.Fprintf(b, "#classes=%s\n", strings.Join(m.classes.labels, ","))
+	} else if m.link != defaultLink {
+		fmt.Fprintf(b, "#link=%s\n", m.link)
+	}
+
+	for _, spec := range m.schema.features {
+		if spec.buckets == nil {
+			continue
+		}
+		items := make([]string, len(spec.buckets))
+		for i, x := range spec.buckets {
+			items[i] = formatFloat(x)
+		}
+		line(BucketsName+"="+string(spec.name), strings.Join(items, ","), nil)
+	}
+
+	if m.hasBias {
+		line(BiasName, coefs(m.bias, func(i int) float64 {
+			return m.classes.bias[i]
+		}), m.biasCount)
+	}
+
+	numeric := make([]FeatureName, 0, len(m.weights))
+	for f := range m.weights {
+		numeric = append(numeric, f)
+	}
+	sort.Slice(numeric, func(i, j int) bool {
+		return m.schema.Name(numeric[i]) < m.schema.Name(numeric[j])
+	})
+	for _, f := range numeric {
+		line(string(m.schema.Name(f)), coefs(m.weights[f], func(i int) float64 {
+			return m.classes.weights[i][f]
+		}), nil)
+	}
+
+	vocab := m.vocabulary()
+
+	variables := make([]Variable, 0, len(m.variables))
+	for v := range m.variables {
+		variables = append(variables, v)
+	}
+	sort.Slice(variables, func(i, j int) bool {
+		return m.labels[variables[i]] < m.labels[variables[j]]
+	})
+	for _, v := range variables {
+		name := m.labels[v]
+
+		type entry struct {
+			key   string
+			value Value
+		}
+		entries := make([]entry, 0, len(m.coef[v]))
+		for value := range m.coef[v] {
+			vals := make([]string, v.size)
+			for i := range vals {
+				vals[i] = vocab[v.feature(i)][value.token(i)]
+			}
+			entries = append(entries, entry{strings.Join(vals, FeatureValueSeparator), value})
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+		for _, e := range entries {
+			var count *float64
+			if n, ok := m.counts[v][e.value]; ok {
+				count = &n
+			}
+			line(name+"="+e.key, coefs(m.coef[v][e.value], func(i int) float64 {
+				return m.classes.coef[i][v][e.value]
+			}), count)
+		}
+
+		if other, ok := m.other[v]; ok {
+			var count *float64
+			if n, ok := m.otherCounts[v]; ok {
+				count = &n
+			}
+			value := OtherValue
+			if m.splitOther[v] {
+				value = strings.Repeat(OtherValue+FeatureValueSeparator, int(v.size)-1) + OtherValue
+			}
+			line(name+"="+value, coefs(other, func(i int) float64 {
+				return m.classes.other[i][v]
+			}), count)
+		}
+	}
+
+	if h := m.hashing; h != nil {
+		for i, w := range h.weights {
+			if w != 0 {
+				line("h="+strconv.Itoa(i), formatFloat(w), nil)
+			}
+		}
+	}
+	return b.Flush()
+}
+
+// vocabulary lists values of each feature by token
+func (m *Model) vocabulary() [][]string {
+	vocab := make([][]string, m.schema.Len())
+	for f := range vocab {
+		vocab[f] = make([]string, m.values.uniqs[FeatureName(f)])
+		for val, token := range m.values.store[FeatureName(f)] {
+			vocab[f][token] = val
+		}
+	}
+	return vocab
+}
+
+// formatFloat formats number so it's parsed back exactly
+func formatFloat(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+// ExportModel serializes model which is being served to text
+// format, updates applied online to serving are included.
+// Shadow copy of online learner is exported on demand
+func (inf *Inferencer) ExportModel(c context.Context,
+	req *pb.ExportRequest) (*pb.ExportResponse, error) {
+
+	h, err := inf.registry.Get(req.GetModelName())
+	if err != nil {
+		return nil, err
+	}
+	m := h.Current()
+	if req.GetOnline() {
+		l := inf.registry.Learner(h.name)
+		if l == nil {
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"online learning is not enabled for model %s", h.name)
+		}
+		m = l.Model()
+	}
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, m); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to export model: %v", err)
+	}
+	return &pb.ExportResponse{
+		ModelName: h.name,
+		Model:     buf.Bytes(),
+		LoadedAt:  m.loadedAt.Unix(),
+	}, nil
+}
@@ -0,0 +1,185 @@
+package serving
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	pb "github.com/go-code/goinfer/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTextRoundTrip(t *testing.T) {
+	models := map[string]string{
+		"plain": `0:bias:-1.5:3
+1:geo=us:1:99
+2:geo=gb:0.1
+3:geo=fit.other:-1:1
+4:geoXXbrowserXXos_version=usX~X8X~Xmac10.12:0.25
+5:geoXXbrowserXXos_version=fit.other:-0.125
+6:buckets=hour:6,12.5
+7:hour=1:0.25
+8:hourXXgeo=2X~Xus:0.75
+9:bid_floor:0.125`,
+		"link": "#link=log\n0:bias:1\n1:geo=us:0.1",
+		"hashed": `#hash=fnv1a32
+#hash_buckets=16
+#variables=geo,geoXXbrowser
+0:bias:0.5
+1:h=3:0.25
+2:h=11:-1e-07`,
+		"classes": classesModel,
+	}
+	reqs := []*pb.Request{
+		{Geo: "us", Browser: 8, OsVersion: "mac10.12", Features: map[string]string{"hour": "7", "bid_floor": "2"}},
+		{Geo: "gb", Browser: 8, Features: map[string]string{"hour": "13"}},
+		{Geo: "de"},
+	}
+
+	for name, content := range models {
+		lines := strings.Split(content, "\n")
+		m, err := parse(&lines, DefaultSchema())
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		var first bytes.Buffer
+		if err := WriteText(&first, m); err != nil {
+			t.Fatal(err)
+		}
+		lines = strings.Split(strings.TrimSuffix(first.String(), "\n"), "\n")
+		decoded, err := parse(&lines, DefaultSchema())
+		if err != nil {
+			t.Fatalf("%s: exported model is invalid: %v\n%s", name, err, first.String())
+		}
+
+		var second bytes.Buffer
+		if err := WriteText(&second, decoded); err != nil {
+			t.Fatal(err)
+		}
+		if first.String() != second.String() {
+			t.Errorf("%s: export isn't stable:\n%s\n%s", name, first.String(), second.String())
+		}
+
+		if len(decoded.variables) != len(m.variables) || decoded.link != m.link || decoded.Variables() != m.Variables() {
+			t.Errorf("%s: decoded model differs", name)
+		}
+		for _, req := range reqs {
+			if m.classes != nil {
+				s1, s2 := m.classScores(req), decoded.classScores(req)
+				for i := range s1 {
+					if math.Abs(s1[i]-s2[i]) > 1e-12 {
+						t.Errorf("%s: %v: class score %v != %v", name, req, s2, s1)
+					}
+				}
+				continue
+			}
+			s1, st1, _ := m.scoreStats(req)
+			s2, st2, _ := decoded.scoreStats(req)
+			if math.Abs(s1-s2) > 1e-12 || st1.found != st2.found ||
+				math.Abs(st1.variance-st2.variance) > 1e-12 {
+				t.Errorf("%s: %v: score %v %v != %v %v", name, req, s2, st2, s1, st1)
+			}
+		}
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	lines := []string{
+		"7:geo=us:1:99",
+		"3:geo=fit.other:-1",
+		"2:bias:0.5",
+		"1:browser=8:0.25",
+	}
+	m, err := parse(&lines, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteText(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	expected := `0:bias:0.5
+1:browser=8:0.25
+2:geo=us:1:99
+3:geo=fit.other:-1
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected text:\n%s", buf.String())
+	}
+}
+
+func TestWriteTextKeepsForm(t *testing.T) {
+	// model without bias, with fallbacks of interactions in both forms
+	source := `0:geo=us:1
+1:geo=fit.other:-1
+2:geoXXbrowser=usX~X8:0.25
+3:geoXXbrowser=fit.otherX~Xfit.other:0.1
+4:geoXXbrowserXXos_version=usX~X8X~Xmac:0.5
+5:geoXXbrowserXXos_version=fit.other:-0.5
+`
+	lines := strings.Split(strings.TrimSuffix(source, "\n"), "\n")
+	m, err := parse(&lines, DefaultSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != source {
+		t.Errorf("exported model differs from source:\n%s", buf.String())
+	}
+
+	// pruning uses the same writer
+	p, err := Prune(m, PruneOptions{Threshold: 0.3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := WriteText(&buf, p); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "geoXXbrowser=fit.otherX~Xfit.other:0.1\n") ||
+		strings.Contains(buf.String(), BiasName) {
+		t.Errorf("pruned model changes form:\n%s", buf.String())
+	}
+}
+
+func TestExportModel(t *testing.T) {
+	h := testHolder(t, "default", countedModel)
+	inf := testInferencer(h)
+
+	resp, err := inf.ExportModel(context.Background(), &pb.ExportRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	WriteText(&buf, h.Current())
+	if resp.ModelName != "default" || !bytes.Equal(resp.Model, buf.Bytes()) {
+		t.Errorf("unexpected export %s", resp.Model)
+	}
+
+	if _, err := inf.ExportModel(context.Background(), &pb.ExportRequest{Online: true}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition without learner, got %v", err)
+	}
+
+	// shadow copy of learner is exported with updates
+	l := testLearner(h, false)
+	inf.registry.learners = map[string]*Learner{"default": l}
+	l.Feedback(&pb.Request{Geo: "us"}, true)
+	resp, err = inf.ExportModel(context.Background(), &pb.ExportRequest{Online: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	WriteText(&buf, l.published)
+	if !bytes.Equal(resp.Model, buf.Bytes()) || !strings.Contains(string(resp.Model), "geo=us:") ||
+		strings.Contains(string(resp.Model), "geo=us:1:99") {
+		t.Errorf("unexpected online export %s", resp.Model)
+	}
+}
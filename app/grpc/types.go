@@ -3,8 +3,9 @@ package serving
 //revive:disable:exported
 
 import (
+	"encoding/binary"
 	"fmt"
-	"strconv"
+	"strings"
 
 	pb "github.com/go-code/goinfer/api"
 )
@@ -12,114 +13,119 @@ import (
 type FeatureName uint8
 
 const (
-	FeatureZoneID FeatureName = iota
-	FeatureBannerID
-	FeatureGeo
-	FeatureBrowser
-	FeatureOsVersion
-
-	TotalFeatureCount     = FeatureOsVersion + 1
 	FeatureNameSeparator  = "XX"
 	FeatureValueSeparator = "X~X"
+
+	// BiasName and InterceptName mark intercept line of model file
+	BiasName      = "bias"
+	InterceptName = "intercept"
+	// BucketsName marks boundaries line of numeric feature
+	BucketsName = "buckets"
+	// OtherValue stands for all values unseen in training
+	OtherValue = "fit.other"
 )
 
-func (f FeatureName) fromRequest(req *pb.Request) (string, error) {
-	switch f {
-	case FeatureZoneID:
-		return strconv.Itoa(int(req.GetZoneId())), nil
-	case FeatureBannerID:
-		return strconv.Itoa(int(req.GetBannerId())), nil
-	case FeatureGeo:
-		return req.GetGeo(), nil
-	case FeatureBrowser:
-		return strconv.Itoa(int(req.GetBrowser())), nil
-	case FeatureOsVersion:
-		return req.GetOsVersion(), nil
-	default:
-		return "", fmt.Errorf("unknown request feature %v", f.StringName())
-	}
+type FeatureNameString string
 
+type Yaml map[interface{}]interface{}
+
+// Variable is an abstraction for handling
+// model factors as interaction of any number
+// of variables.
+// Is used for fetching and packing fields
+// of grpc request
+//
+// Features are packed to string one byte per
+// feature, so Variable stays comparable and
+// can be used as map key
+type Variable struct {
+	size     uint8
+	features string
 }
 
-type FeatureNameString string
+// newVariable packs interaction of features
+func newVariable(features ...FeatureName) Variable {
+	b := make([]byte, len(features))
+	for i, f := range features {
+		b[i] = byte(f)
+	}
+	return Variable{size: uint8(len(features)), features: string(b)}
+}
 
-var featureNameToString = map[FeatureName]FeatureNameString{
-	FeatureZoneID:    "zone_id",
-	FeatureBannerID:  "banner_id",
-	FeatureGeo:       "geo",
-	FeatureBrowser:   "browser",
-	FeatureOsVersion: "os_version",
+// feature returns i-th feature of interaction
+func (v Variable) feature(i int) FeatureName {
+	return FeatureName(v.features[i])
 }
 
-var featureNameFromString = make(
-	map[FeatureNameString]FeatureName, TotalFeatureCount,
-)
+// makeValue fetches variable values from request and
+// enumerates them. MissingValueError is returned for
+// values unseen in training
+func (v Variable) makeValue(req *pb.Request, schema *Schema,
+	kv *KVstore) (Value, error) {
 
-func initFeatureNameFromString() {
-	for f := FeatureZoneID; f < TotalFeatureCount; f++ {
-		featureNameFromString[featureNameToString[f]] = f
+	if v.size == 0 {
+		return Value{}, fmt.Errorf("Nothing to return")
 	}
-}
 
-func (f FeatureName) StringName() FeatureNameString {
-	return featureNameToString[f]
+	tokens := make([]uint32, v.size)
+	for i := range tokens {
+		f := v.feature(i)
+		val, err := schema.fromRequest(f, req)
+		if err != nil {
+			return Value{}, err
+		}
+		if tokens[i], err = kv.Get(f, val); err != nil {
+			return Value{}, err
+		}
+	}
+	return newValue(tokens...), nil
 }
 
-type Yaml map[interface{}]interface{}
-
-// Variable is an abstraction for handling
-// model factors as interaction of one or
-// two variables.
-// Is used for fetching and packing fields
-// of grpc request
-type Variable struct {
-	size uint8
-	x, y FeatureName
+// Name formats variable with feature names from schema
+func (v Variable) Name(schema *Schema) string {
+	return "{" + strings.Join(v.names(schema), ", ") + "}"
 }
 
-func (v Variable) makeValue(req *pb.Request, kv *KVstore) (Value, error) {
-	switch v.size {
-	case 1:
-		val, _ := v.x.fromRequest(req)
-		res, _ := kv.Get(v.x, val)
-		return Value{size: 1, x: res}, nil
-	case 2:
-		val1, _ := v.x.fromRequest(req)
-		val2, _ := v.y.fromRequest(req)
-		res1, _ := kv.Get(v.x, val1)
-		res2, _ := kv.Get(v.y, val2)
-		return Value{size: 2, x: res1, y: res2}, nil
-	default:
-		return Value{}, fmt.Errorf("Nothing to return")
-	}
+// fileName formats variable as in model file
+func (v Variable) fileName(schema *Schema) string {
+	return strings.Join(v.names(schema), FeatureNameSeparator)
 }
 
-func (v Variable) String() string {
-	switch v.size {
-	case 0:
-		return fmt.Sprintf("{}")
-	case 1:
-		return fmt.Sprintf("{%v}",
-			featureNameToString[v.x],
-		)
-	case 2:
-		return fmt.Sprintf("{%v, %v}",
-			featureNameToString[v.x],
-			featureNameToString[v.y],
-		)
-	default:
-		return fmt.Sprintf("{}")
+// names lists feature names of variable
+func (v Variable) names(schema *Schema) []string {
+	names := make([]string, v.size)
+	for i := range names {
+		names[i] = string(schema.Name(v.feature(i)))
 	}
+	return names
 }
 
 // Value is an abstraction for handling
 // model factor values, which might contain
-// one or two values
+// any number of values.
+//
+// Enumerated values are packed to string
+// four bytes per value
 type Value struct {
-	size uint8
-	x, y uint32
+	size   uint8
+	tokens string
+}
+
+// newValue packs enumerated values of interaction
+func newValue(tokens ...uint32) Value {
+	b := make([]byte, 4*len(tokens))
+	for i, t := range tokens {
+		binary.LittleEndian.PutUint32(b[4*i:], t)
+	}
+	return Value{size: uint8(len(tokens)), tokens: string(b)}
+}
+
+// token returns i-th enumerated value
+func (v Value) token(i int) uint32 {
+	return binary.LittleEndian.Uint32([]byte(v.tokens[4*i : 4*i+4]))
 }
 
 type VariableSet map[Variable]bool
 type ValueStore map[Value]float64
 type CoeffStore map[Variable]ValueStore
+type OtherStore map[Variable]float64
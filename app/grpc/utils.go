@@ -2,114 +2,279 @@ package serving
 
 import (
 	"bufio"
-	"log"
+	"fmt"
+	"io"
 	"math"
-	"os"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/chapsuk/wait"
 )
 
-// Loads model from filename pointed in config file
-func (inf *Inferencer) loadModel(config Yaml) {
-	path := config["model"].(string)
-	lines, err := scanfile(path)
-	if err != nil {
-		log.Fatalf("Failed to read file: %v", err)
-	}
-
-	kv, vars, coef, err := parse(lines)
-	if err != nil {
-		log.Fatalf("Failed to parse model: %v", err)
-	}
-	inf.variables = *vars
-	inf.values = *kv
-	inf.coef = *coef
-
-	log.Printf("Model have loaded successfully!")
-	for k, v := range inf.coef {
-		log.Println(k, len(v))
-	}
-}
-
-func scanfile(path string) (*[]string, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return &[]string{}, err
-	}
-
-	scanner := bufio.NewScanner(file)
+func scanlines(r io.Reader) (*[]string, error) {
+	scanner := bufio.NewScanner(r)
 	lines := make([]string, 0, 1000)
 	for scanner.Scan() {
 		line := scanner.Text()
 		lines = append(lines, line)
 	}
+	if err := scanner.Err(); err != nil {
+		return &[]string{}, err
+	}
 
 	return &lines, nil
 }
 
-func parse(lines *[]string) (*KVstore, *VariableSet, *CoeffStore, error) {
+func parse(lines *[]string, schema *Schema) (*Model, error) {
 	// Format of each line is
 	// <positional No. of feature>:<name>=<value>:<coefficient>
 	// Positional no. of feature is useless for inference
 	// so it's just ignored
+	//
+	// Interactions of any order join names by "XX" and values by "X~X"
+	// <positional No.>:geoXXbrowserXXos_version=usX~X8X~Xmac10.12:<coefficient>
+	//
+	// Value "fit.other" stands for all values unseen in training,
+	// its coefficient is used as fallback for such values.
+	// Interaction has either single "fit.other" or one for each
+	// of its features, e.g. "fit.otherX~Xfit.other"
+	//
+	// Intercept of model is the line without value
+	// <positional No.>:bias:<coefficient>
+	//
+	// Numeric feature without value is multiplied by its weight
+	// <positional No.>:<name>:<weight>
+	// or bucketized by increasing boundaries, index of bucket
+	// is used as its value then
+	// <positional No.>:buckets=<name>:<boundary>,<boundary>,...
+	//
+	// Coefficient may be followed by goFTRL "n" accumulator
+	// <positional No.>:<name>=<value>:<coefficient>:<count>
+	// it's kept for values, "fit.other" and bias (see confidence),
+	// counts of numeric weights and hashed buckets are ignored
+	//
+	// Leading "#<key>=<value>" lines are model header,
+	// hashed model has "h=<bucket>" instead of values (see Hashing),
+	// "#link=<name>" declares link function of model (see linkFuncs)
+	// and "#classes=<label>,..." makes model multi-class (see Classes)
+	//
+	// Features missing in schema are added to it, unless schema
+	// is strict. Problems of all lines are collected to ModelErrors
 	valuestore := NewKVStore()
 	features := make(VariableSet)
 	coefstore := make(CoeffStore)
+	otherstore := make(OtherStore)
+	var bias *float64
+	weights := make(map[FeatureName]float64)
+
+	counts := make(CoeffStore)
+	othercounts := make(OtherStore)
+	var biascount *float64
+	splitother := make(VariableSet)
 
-	for _, line := range *lines {
+	// values of numeric features are checked when
+	// boundaries of all features are known
+	type numericUse struct {
+		line    int
+		feature FeatureName
+		value   string
+	}
+	var uses []numericUse
+
+	var errs ModelErrors
+	fail := func(line int, format string, args ...interface{}) {
+		errs = append(errs, &ModelError{
+			Line:   line,
+			Reason: fmt.Sprintf(format, args...),
+		})
+	}
 
-		var no, feature, coef string
-		unpackArray(strings.Split(line, ":"), &no, &feature, &coef)
+	header, start, herrs := parseHeader(*lines)
+	errs = append(errs, herrs...)
 
-		c, err := strconv.ParseFloat(coef, 64)
-		if err != nil {
-			log.Fatalf("Failed to parse coefficient %s", line)
+	hashing, herr := hashingFromHeader(header, schema)
+	if herr != nil {
+		errs = append(errs, herr)
+	}
+	hashed := make(map[uint32]bool)
+
+	link, lerr := linkFromHeader(header)
+	if lerr != nil {
+		errs = append(errs, lerr)
+	}
+
+	classes, cerr := classesFromHeader(header)
+	if cerr != nil {
+		errs = append(errs, cerr)
+	}
+	k := 1
+	if classes != nil {
+		k = len(classes.labels)
+	}
+
+	for i, line := range (*lines)[start:] {
+		lineNo := start + i + 1
+		if len(errs) >= maxModelErrors {
+			break
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
 		}
 
-		var fname, fval string
-		unpackArray(strings.Split(feature, "="), &fname, &fval)
+		parts := strings.Split(line, ":")
+		if len(parts) != 3 && len(parts) != 4 {
+			fail(lineNo, "expected <no>:<name>=<value>:<coefficient>[:<count>], got %q", line)
+			continue
+		}
+		var no, feature, coef, count string
+		unpackArray(parts, &no, &feature, &coef, &count)
 
-		if strings.Contains(fval, "fit.other") {
+		var n *float64
+		if len(parts) == 4 {
+			x, err := strconv.ParseFloat(count, 64)
+			if err != nil || x < 0 || math.IsInf(x, 0) {
+				fail(lineNo, "count must be non-negative number, got %q", count)
+				continue
+			}
+			n = &x
+		}
+
+		if strings.HasPrefix(feature, BucketsName+"=") {
+			if n != nil {
+				fail(lineNo, "buckets have no count")
+				continue
+			}
+			fname := strings.TrimPrefix(feature, BucketsName+"=")
+			buckets, err := parseBuckets(coef)
+			if err != nil {
+				fail(lineNo, "%v", err)
+				continue
+			}
+			types := make([]FeatureName, 1)
+			if err := typelook(schema, []string{fname}, types); err != nil {
+				fail(lineNo, "%v", err)
+				continue
+			}
+			if err := schema.setBuckets(types[0], buckets); err != nil {
+				fail(lineNo, "%v", err)
+			}
 			continue
 		}
 
-		variable := Variable{}
-		value := Value{}
-		if strings.Contains(fname, "XX") {
-			var l, r string
-			unpackArray(strings.Split(fname, FeatureNameSeparator), &l, &r)
+		coefs, err := parseCoefs(coef, k)
+		if err != nil {
+			fail(lineNo, "%v", err)
+			continue
+		}
+		c := coefs[0]
+		if classes != nil && n != nil {
+			fail(lineNo, "multi-class model has no counts")
+			continue
+		}
+
+		if feature == BiasName || feature == InterceptName {
+			if bias != nil {
+				fail(lineNo, "duplicate key %s", feature)
+			}
+			bias = &c
+			biascount = n
+			if classes != nil {
+				classes.bias = coefs
+			}
+			continue
+		}
+
+		if hashing != nil && strings.HasPrefix(feature, "h=") {
+			if err := hashing.set(feature, c, hashed); err != nil {
+				fail(lineNo, "%v", err)
+			}
+			continue
+		}
 
-			var ltype, rtype FeatureName
-			typelook([]string{l, r}, &ltype, &rtype)
+		if !strings.Contains(feature, "=") {
+			types := make([]FeatureName, 1)
+			if err := typelook(schema, []string{feature}, types); err != nil {
+				fail(lineNo, "%v", err)
+				continue
+			}
+			if err := schema.setNumeric(types[0]); err != nil {
+				fail(lineNo, "%v", err)
+				continue
+			}
+			if _, dup := weights[types[0]]; dup {
+				fail(lineNo, "duplicate key %s", feature)
+			}
+			weights[types[0]] = c
+			if classes != nil {
+				classes.setWeight(types[0], coefs)
+			}
+			continue
+		}
 
-			variable = Variable{size: 2, x: ltype, y: rtype}
-			features[variable] = true
+		pair := strings.SplitN(feature, "=", 2)
+		if hashing != nil {
+			fail(lineNo, "model is hashed, expected h=<bucket>, got %q", feature)
+			continue
+		}
+		if pair[0] == "" {
+			fail(lineNo, "expected <name>=<value>, got %q", feature)
+			continue
+		}
+		var fname, fval string
+		unpackArray(pair, &fname, &fval)
 
-			var lval, rval string
-			unpackArray(
-				strings.Split(fval, FeatureValueSeparator),
-				&lval, &rval)
-			ltoken, _ := valuestore.Set(ltype, lval)
-			rtoken, _ := valuestore.Set(rtype, rval)
+		names := strings.Split(fname, FeatureNameSeparator)
+		vals := strings.Split(fval, FeatureValueSeparator)
 
-			value = Value{
-				size: 2,
-				x:    ltoken,
-				y:    rtoken,
+		others := 0
+		for _, val := range vals {
+			if val == OtherValue {
+				others++
 			}
-		} else {
-			ftype := featureNameFromString[FeatureNameString(fname)]
-			variable = Variable{size: 1, x: ftype}
-			features[variable] = true
+		}
+		other := others > 0
+		if other && others != len(vals) {
+			fail(lineNo, "partial fallback %q isn't supported, all values must be %s", feature, OtherValue)
+			continue
+		}
+		if len(names) > math.MaxUint8 || (len(vals) != len(names) && !(other && len(vals) == 1)) {
+			fail(lineNo, "malformed interaction %q", feature)
+			continue
+		}
+
+		types := make([]FeatureName, len(names))
+		if err := typelook(schema, names, types); err != nil {
+			fail(lineNo, "%v", err)
+			continue
+		}
+
+		variable := newVariable(types...)
+		features[variable] = true
 
-			token, _ := valuestore.Set(ftype, fval)
-			value = Value{
-				size: 1,
-				x:    token,
+		if other {
+			if _, dup := otherstore[variable]; dup {
+				fail(lineNo, "duplicate key %s", feature)
 			}
+			otherstore[variable] = c
+			if len(vals) > 1 {
+				splitother[variable] = true
+			}
+			if n != nil {
+				othercounts[variable] = *n
+			}
+			if classes != nil {
+				classes.setOther(variable, coefs)
+			}
+			continue
+		}
+
+		tokens := make([]uint32, len(vals))
+		for i, val := range vals {
+			tokens[i], _ = valuestore.Set(types[i], val)
+			uses = append(uses, numericUse{lineNo, types[i], val})
 		}
+		value := newValue(tokens...)
 
 		inner, ok := coefstore[variable]
 		if !ok {
@@ -117,11 +282,85 @@ func parse(lines *[]string) (*KVstore, *VariableSet, *CoeffStore, error) {
 			inner[value] = c
 			coefstore[variable] = inner
 		} else {
+			if _, dup := inner[value]; dup {
+				fail(lineNo, "duplicate key %s", feature)
+			}
 			coefstore[variable][value] = c
 		}
+
+		if classes != nil {
+			classes.setCoef(variable, value, coefs)
+		}
+
+		if n != nil {
+			if counts[variable] == nil {
+				counts[variable] = make(ValueStore)
+			}
+			counts[variable][value] = *n
+		}
+	}
+
+	for _, use := range uses {
+		spec := schema.features[use.feature]
+		if spec.kind != kindNumeric {
+			continue
+		}
+		if spec.buckets == nil {
+			fail(use.line, "numeric feature %s has no buckets", spec.name)
+			continue
+		}
+		if i, err := strconv.Atoi(use.value); err != nil || i < 0 || i > len(spec.buckets) {
+			fail(use.line, "bucket %q of %s is out of range", use.value, spec.name)
+		}
 	}
 
-	return valuestore, &features, &coefstore, nil
+	// empty or truncated file mustn't replace serving model
+	if len(errs) == 0 && bias == nil && len(weights) == 0 && len(coefstore) == 0 &&
+		len(otherstore) == 0 && len(hashed) == 0 {
+		fail(len(*lines), "model has no coefficients")
+	}
+
+	if len(errs) > 0 {
+		sort.SliceStable(errs, func(i, j int) bool {
+			return errs[i].Line < errs[j].Line
+		})
+		if len(errs) > maxModelErrors {
+			errs = errs[:maxModelErrors]
+		}
+		return nil, errs
+	}
+	m := newModel(schema, features, *valuestore, coefstore, otherstore)
+	if bias != nil {
+		m.bias = *bias
+		m.hasBias = true
+	}
+	m.weights = weights
+	m.hashing = hashing
+	m.link = link
+	if classes != nil {
+		m.classes = classes
+		m.link = linkSoftmax
+	}
+	m.counts = counts
+	m.otherCounts = othercounts
+	m.biasCount = biascount
+	m.splitOther = splitother
+	m.compiled = compile(m)
+	return m, nil
+}
+
+// parseBuckets reads comma separated boundaries of buckets
+func parseBuckets(s string) ([]float64, error) {
+	items := strings.Split(s, ",")
+	buckets := make([]float64, len(items))
+	for i, item := range items {
+		b, err := strconv.ParseFloat(strings.TrimSpace(item), 64)
+		if err != nil || math.IsNaN(b) || math.IsInf(b, 0) {
+			return nil, fmt.Errorf("failed to parse bucket boundary %q", item)
+		}
+		buckets[i] = b
+	}
+	return buckets, nil
 }
 
 func unpackArray(arr []string, vars ...*string) {
@@ -130,10 +369,15 @@ func unpackArray(arr []string, vars ...*string) {
 	}
 }
 
-func typelook(vars []string, types ...*FeatureName) {
+func typelook(schema *Schema, vars []string, types []FeatureName) error {
 	for i, v := range vars {
-		*types[i] = featureNameFromString[FeatureNameString(v)]
+		ftype, err := schema.resolve(FeatureNameString(v))
+		if err != nil {
+			return err
+		}
+		types[i] = ftype
 	}
+	return nil
 }
 
 // Sigmoid transforms range [-inf, inf] to [-1, 1]
@@ -0,0 +1,69 @@
+package serving
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxModelErrors limits number of problems reported for model file
+const maxModelErrors = 100
+
+// ModelError describes problem of model file line
+type ModelError struct {
+	File   string
+	Line   int
+	Reason string
+}
+
+func (e *ModelError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Reason)
+}
+
+// ModelErrors are all problems found in model file
+type ModelErrors []*ModelError
+
+func (e ModelErrors) Error() string {
+	const shown = 3
+
+	msgs := make([]string, 0, shown+1)
+	for i, err := range e {
+		if i == shown {
+			msgs = append(msgs, fmt.Sprintf("and %d more", len(e)-shown))
+			break
+		}
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ValidateModel loads model file without serving it.
+// Problems of model lines are reported as ModelErrors
+func ValidateModel(path string, schema *Schema) (*Model, error) {
+	return loadModel("", path, schema)
+}
+
+// ConvertModel loads model file of any format and writes
+// it in binary format
+func ConvertModel(in, out string, schema *Schema) (*Model, error) {
+	m, err := loadModel("", in, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Create(out)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(file)
+	if err := WriteBinary(w, m); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return m, file.Close()
+}
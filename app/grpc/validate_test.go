@@ -0,0 +1,77 @@
+package serving
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	strict := DefaultSchema()
+	strict.strict = true
+
+	cases := []struct {
+		line   string
+		schema *Schema
+		reason string
+	}{
+		{"0:geo=us:NaN", DefaultSchema(), "coefficient is NaN"},
+		{"0:geo=us:+Inf", DefaultSchema(), "coefficient is +Inf"},
+		{"0:geo=us:x", DefaultSchema(), "failed to parse coefficient"},
+		{"0:geo=us:1:2:3", DefaultSchema(), "expected <no>:<name>=<value>:<coefficient>"},
+		{"0:geo=us:1:-2", DefaultSchema(), "count must be non-negative"},
+		{"0:buckets=age:1,2:3", DefaultSchema(), "buckets have no count"},
+		{"0:=us:1", DefaultSchema(), "expected <name>=<value>"},
+		{"0:geo:1", DefaultSchema(), "feature geo is categorical"},
+		{"0:buckets=age:10,5", DefaultSchema(), "must be increasing"},
+		{"0:buckets=age:10,x", DefaultSchema(), "failed to parse bucket boundary"},
+		{"0:geoXXbrowser=us:1", DefaultSchema(), "malformed interaction"},
+		{"0:geoXX=usX~X1:1", DefaultSchema(), "unknown feature"},
+		{"0:segment=sport:1", strict, "unknown feature"},
+		{"0:geo=gb:1", DefaultSchema(), "duplicate key"},
+		{"0:geoXXbrowser=usX~Xfit.other:1", DefaultSchema(), "partial fallback"},
+		{"0:geoXXbrowser=fit.otherX~X8:1", DefaultSchema(), "partial fallback"},
+		{"0:geoXXbrowser=fit.otherX~Xfit.otherX~Xfit.other:1", DefaultSchema(), "malformed interaction"},
+		// found by fuzzing, name becomes label of metric of unseen values
+		{":\xc2=0:0", DefaultSchema(), "isn't valid UTF-8"},
+		{"0:geoXX\xff=usX~X1:1", DefaultSchema(), "isn't valid UTF-8"},
+	}
+
+	for _, c := range cases {
+		lines := []string{"0:geo=gb:1", "", c.line}
+		_, err := parse(&lines, c.schema)
+
+		errs, ok := err.(ModelErrors)
+		if !ok || len(errs) != 1 {
+			t.Errorf("%s: expected single ModelError, got %v", c.line, err)
+			continue
+		}
+		if errs[0].Line != 3 || !strings.Contains(errs[0].Reason, c.reason) {
+			t.Errorf("%s: expected %q at line 3, got %v", c.line, c.reason, errs[0])
+		}
+	}
+}
+
+func TestParseNumericErrors(t *testing.T) {
+	cases := []struct {
+		lines  []string
+		line   int
+		reason string
+	}{
+		{[]string{"0:age:1", "1:age=2:1"}, 2, "numeric feature age has no buckets"},
+		{[]string{"0:age=3:1", "1:buckets=age:10,20"}, 1, "bucket \"3\" of age is out of range"},
+		{[]string{"0:buckets=age:10", "1:buckets=age:20"}, 2, "already has buckets"},
+	}
+
+	for _, c := range cases {
+		_, err := parse(&c.lines, DefaultSchema())
+
+		errs, ok := err.(ModelErrors)
+		if !ok || len(errs) != 1 {
+			t.Errorf("%v: expected single ModelError, got %v", c.lines, err)
+			continue
+		}
+		if errs[0].Line != c.line || !strings.Contains(errs[0].Reason, c.reason) {
+			t.Errorf("%v: expected %q at line %d, got %v", c.lines, c.reason, c.line, errs[0])
+		}
+	}
+}
@@ -13,14 +13,93 @@ var (
 			Help:    "Probability calculate latency (histogram)",
 			Buckets: []float64{.0001, .0005, .005, .015, .1},
 		},
-		[]string{"step"},
+		[]string{"step", "model", "variant"},
+	)
+
+	modelReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "model_reloads_total",
+			Help: "Model reload attempts by model and status (counter)",
+		},
+		[]string{"model", "status"},
+	)
+
+	modelLoadedAt = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "model_loaded_timestamp_seconds",
+			Help: "Unix time of the last successful model load (gauge)",
+		},
+		[]string{"model"},
+	)
+
+	unseenValues = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "unseen_values_total",
+			Help: "Variable values unseen in training, served with fit.other fallback (counter)",
+		},
+		[]string{"model", "variable"},
+	)
+
+	shadowDelta = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "shadow_proba_delta",
+			Help:    "Absolute difference of primary and candidate probabilities (histogram)",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5},
+		},
+		[]string{"model", "candidate"},
+	)
+
+	shadowDisagreements = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "shadow_sign_disagreements_total",
+			Help: "Requests where primary and candidate are on different sides of threshold (counter)",
+		},
+		[]string{"model", "candidate"},
+	)
+
+	shadowSkipped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "shadow_skipped_total",
+			Help: "Requests not scored by candidate by reason (counter)",
+		},
+		[]string{"model", "candidate", "reason"},
 	)
 )
 
-func ProbabilityLatency(step string, duration float64) {
-	probabilityLatency.WithLabelValues(step).Observe(duration)
+func ProbabilityLatency(step, model, variant string, duration float64) {
+	probabilityLatency.WithLabelValues(step, model, variant).Observe(duration)
+}
+
+func ModelReload(model, status string) {
+	modelReloads.WithLabelValues(model, status).Inc()
+}
+
+func ModelLoaded(model string, timestamp float64) {
+	modelLoadedAt.WithLabelValues(model).Set(timestamp)
+}
+
+func UnseenValue(model, variable string) {
+	unseenValues.WithLabelValues(model, variable).Inc()
+}
+
+func ShadowDelta(model, candidate string, delta float64) {
+	shadowDelta.WithLabelValues(model, candidate).Observe(delta)
+}
+
+func ShadowDisagreement(model, candidate string) {
+	shadowDisagreements.WithLabelValues(model, candidate).Inc()
+}
+
+func ShadowSkipped(model, candidate, reason string) {
+	shadowSkipped.WithLabelValues(model, candidate, reason).Inc()
 }
 
 func init() {
 	prometheus.MustRegister(probabilityLatency)
+	prometheus.MustRegister(modelReloads)
+	prometheus.MustRegister(modelLoadedAt)
+	prometheus.MustRegister(unseenValues)
+	prometheus.MustRegister(shadowDelta)
+	prometheus.MustRegister(shadowDisagreements)
+	prometheus.MustRegister(shadowSkipped)
 }
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	pb "github.com/go-code/goinfer/api"
+	serving "github.com/go-code/goinfer/app/grpc"
+	"google.golang.org/grpc"
+)
+
+// commands are offline tools run as
+//
+//	goinfer <command> [flags] [args]
+var commands = map[string]func(args []string) int{
+	"validate-model": validateModel,
+	"convert-model":  convertModel,
+	"export-model":   exportModel,
+	"model-diff":     modelDiff,
+	"model-prune":    modelPrune,
+}
+
+// schemaFlag reads base schema from config pointed by -config flag,
+// default schema is used if flag is empty
+func schemaFlag(fs *flag.FlagSet) func() (*serving.Schema, error) {
+	path := fs.String("config", "", "config file with features declaration")
+	return func() (*serving.Schema, error) {
+		if *path == "" {
+			return serving.DefaultSchema(), nil
+		}
+		return serving.SchemaFromConfig(loadConfig(*path))
+	}
+}
+
+// validateModel checks model files and reports all problems found,
+// exit code is non-zero if any file is invalid
+func validateModel(args []string) int {
+	fs := flag.NewFlagSet("validate-model", flag.ExitOnError)
+	schema := schemaFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: goinfer validate-model [-config path] model...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	s, err := schema()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	code := 0
+	for _, path := range fs.Args() {
+		m, err := serving.ValidateModel(path, s)
+		switch errs := err.(type) {
+		case nil:
+			fmt.Printf("%s: ok, %d variables, bias %v\n",
+				path, m.Variables(), m.Bias())
+			continue
+		case serving.ModelErrors:
+			for _, e := range errs {
+				fmt.Fprintln(os.Stderr, e)
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		}
+		code = 1
+	}
+	return code
+}
+
+// convertModel writes model in binary format,
+// input may be in either format
+func convertModel(args []string) int {
+	fs := flag.NewFlagSet("convert-model", flag.ExitOnError)
+	schema := schemaFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: goinfer convert-model [-config path] input output")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	s, err := schema()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	in, out := fs.Arg(0), fs.Arg(1)
+	m, err := serving.ConvertModel(in, out, s)
+	if errs, ok := err.(serving.ModelErrors); ok {
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", in, err)
+		return 1
+	}
+
+	fmt.Printf("%s: converted to %s, %d variables\n", in, out, m.Variables())
+	return 0
+}
+
+// exportModel downloads model which is being served by running
+// server in text format, "-" output stands for stdout
+func exportModel(args []string) int {
+	fs := flag.NewFlagSet("export-model", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:"+GRPCP_PORT, "grpc address of server")
+	model := fs.String("model", "", "name of model, default model if empty")
+	online := fs.Bool("online", false, "export copy updated by online learning")
+	timeout := fs.Duration("timeout", time.Minute, "timeout of export")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: goinfer export-model [-addr host:port] [-model name] [-online] output")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, *addr, grpc.WithInsecure())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *addr, err)
+		return 1
+	}
+	defer conn.Close()
+
+	resp, err := pb.NewInferencerClient(conn).ExportModel(ctx,
+		&pb.ExportRequest{ModelName: *model, Online: *online})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *addr, err)
+		return 1
+	}
+
+	out := fs.Arg(0)
+	if out == "-" {
+		os.Stdout.Write(resp.Model)
+		return 0
+	}
+	if err := ioutil.WriteFile(out, resp.Model, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "%s: exported model %s to %s\n", *addr, resp.ModelName, out)
+	return 0
+}
+
+// modelDiff reports what has changed between two model files,
+// exit code is non-zero if either of them is invalid
+func modelDiff(args []string) int {
+	fs := flag.NewFlagSet("model-diff", flag.ExitOnError)
+	schema := schemaFlag(fs)
+	top := fs.Int("top", 20, "number of coefficient deltas to report")
+	sample := fs.String("sample", "", "JSONL file of requests to compare scores on")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: goinfer model-diff [-config path] [-top n] [-sample requests.jsonl] old new")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 || *top < 0 {
+		fs.Usage()
+		return 2
+	}
+
+	s, err := schema()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	models := make([]*serving.Model, 2)
+	for i, path := range fs.Args() {
+		m, err := serving.ValidateModel(path, s)
+		if errs, ok := err.(serving.ModelErrors); ok {
+			for _, e := range errs {
+				fmt.Fprintln(os.Stderr, e)
+			}
+			return 1
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			return 1
+		}
+		models[i] = m
+	}
+
+	diff := serving.DiffModels(models[0], models[1], *top)
+	if *sample != "" {
+		reqs, err := serving.LoadRequests(*sample)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		diff.Scores = serving.CompareScores(models[0], models[1], reqs)
+	}
+
+	if err := diff.Write(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// modelPrune drops small coefficients of model and reports memory
+// saved and change of scores on sample requests
+func modelPrune(args []string) int {
+	fs := flag.NewFlagSet("model-prune", flag.ExitOnError)
+	schema := schemaFlag(fs)
+	threshold := fs.Float64("threshold", 0, "drop coefficients with smaller magnitude")
+	top := fs.Int("top", 0, "keep at most n coefficients with the largest magnitude per variable")
+	sample := fs.String("sample", "", "JSONL file of requests to compare scores on")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: goinfer model-prune [-config path] [-threshold x] [-top n] [-sample requests.jsonl] input output")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 || *threshold < 0 || *top < 0 || (*threshold == 0 && *top == 0) {
+		fs.Usage()
+		return 2
+	}
+
+	s, err := schema()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var reqs []*pb.Request
+	if *sample != "" {
+		if reqs, err = serving.LoadRequests(*sample); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	in, out := fs.Arg(0), fs.Arg(1)
+	opts := serving.PruneOptions{Threshold: *threshold, Top: *top}
+	before, after, err := serving.PruneModel(in, out, s, opts)
+	if errs, ok := err.(serving.ModelErrors); ok {
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", in, err)
+		return 1
+	}
+
+	s1, s2 := before.Size(), after.Size()
+	fmt.Printf("%s: pruned to %s, %d variables\n", in, out, after.Variables())
+	fmt.Printf("  coefficients: %d -> %d\n", s1.Coefficients, s2.Coefficients)
+	fmt.Printf("  values: %d -> %d\n", s1.Values, s2.Values)
+	saved := s1.Bytes - s2.Bytes
+	var percent float64
+	if s1.Bytes > 0 {
+		percent = 100 * float64(saved) / float64(s1.Bytes)
+	}
+	fmt.Printf("  memory: ~%d -> ~%d bytes, %d saved (%.1f%%)\n", s1.Bytes, s2.Bytes, saved, percent)
+	if reqs != nil {
+		scores := serving.CompareScores(before, after, reqs)
+		fmt.Printf("  scores: %d requests, %d failed, max abs delta %g, max prediction delta %g\n",
+			scores.Requests, scores.Failed, scores.Max, scores.MaxProba)
+	}
+	return 0
+}
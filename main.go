@@ -4,6 +4,7 @@ import (
 	"context"
 	"io/ioutil"
 	"log"
+	"os"
 
 	gateway "github.com/go-code/goinfer/app/gateway"
 	serving "github.com/go-code/goinfer/app/grpc"
@@ -38,6 +39,12 @@ func loadConfig(path string) serving.Yaml {
 
 func main() {
 
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
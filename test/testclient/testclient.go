@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"flag"
+	"io"
 	"log"
 	"time"
 
@@ -11,8 +13,17 @@ import (
 
 const (
 	adress = "localhost:50077"
+	total  = 10000000
 )
 
+var request = &pb.Request{
+	BannerId:  4054199,
+	Geo:       "us",
+	ZoneId:    1093182,
+	Browser:   8,
+	OsVersion: "mac10.12",
+}
+
 func sendrequest(req *pb.Request, client pb.InferencerClient) (*pb.Response, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
 	defer cancel()
@@ -22,7 +33,56 @@ func sendrequest(req *pb.Request, client pb.InferencerClient) (*pb.Response, err
 	return resp, err
 }
 
+func unary(client pb.InferencerClient) int {
+	success := 0
+	for i := 0; i < total; i++ {
+		_, err := sendrequest(request, client)
+		if err == nil {
+			success++
+			// fmt.Println(res)
+		}
+	}
+	return success
+}
+
+func stream(client pb.InferencerClient) int {
+	s, err := client.PredictProbaStream(context.Background())
+	if err != nil {
+		log.Fatalf("Cannot open stream: %v", err)
+	}
+
+	go func() {
+		for i := 0; i < total; i++ {
+			err := s.Send(&pb.StreamRequest{Id: uint64(i), Request: request})
+			if err != nil {
+				log.Printf("Failed to send: %v", err)
+				break
+			}
+		}
+		s.CloseSend()
+	}()
+
+	success := 0
+	for {
+		resp, err := s.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("Stream is closed: %v", err)
+			break
+		}
+		if resp.GetResponse() != nil {
+			success++
+		}
+	}
+	return success
+}
+
 func main() {
+	useStream := flag.Bool("stream", false, "send requests over PredictProbaStream")
+	flag.Parse()
+
 	conn, err := grpc.Dial(adress, grpc.WithInsecure())
 	if err != nil {
 		log.Fatalf("Cannot connect to %s", adress)
@@ -32,20 +92,11 @@ func main() {
 	client := pb.NewInferencerClient(conn)
 
 	t := time.Now()
-	success := 0
-	for i := 0; i < 10000000; i++ {
-		_, err := sendrequest(&pb.Request{
-			BannerId:  4054199,
-			Geo:       "us",
-			ZoneId:    1093182,
-			Browser:   8,
-			OsVersion: "mac10.12",
-		},
-			client)
-		if err == nil {
-			success++
-			// fmt.Println(res)
-		}
+	var success int
+	if *useStream {
+		success = stream(client)
+	} else {
+		success = unary(client)
 	}
 
 	log.Println("Finished in ", time.Since(t), "Success:", success)